@@ -1,80 +1,285 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
 	"upbot-server-go/config"
 	"upbot-server-go/internal/api/handlers"
 	"upbot-server-go/internal/api/middleware"
 	"upbot-server-go/internal/infrastructure"
+	"upbot-server-go/internal/logging"
+	"upbot-server-go/internal/metrics"
 	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/oidc"
 	"upbot-server-go/internal/repository"
 	"upbot-server-go/internal/service"
+	"upbot-server-go/internal/tracing"
 	"upbot-server-go/internal/worker"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
 	// 1. Load Config
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
 	}
+	logging.Setup(cfg.LogLevel)
 
 	// 2. Infrastructure
+	// Tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		ServiceName: cfg.TracingServiceName,
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
 	// Database
 	db, err := infrastructure.NewDatabaseConnection(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", err)
 	}
 	// Redis
 	redisClient, err := infrastructure.NewRedisClient(cfg.RedisAddr)
 	if err != nil {
-		log.Fatalf("Failed to connect to redis: %v", err)
+		fatal("Failed to connect to redis", err)
+	}
+	// Metrics
+	if err := metrics.InstrumentGORM(db); err != nil {
+		fatal("Failed to instrument database", err)
+	}
+	redisClient.AddHook(metrics.RedisHook())
+	if err := tracing.InstrumentGORM(db); err != nil {
+		fatal("Failed to instrument database", err)
 	}
+	redisClient.AddHook(tracing.RedisHook())
+	prometheus.MustRegister(metrics.NewQueueCollector(redisClient))
 	// Email
-	emailClient := infrastructure.NewEmailClient(cfg.ResendAPIKey)
+	emailClient := infrastructure.NewEmailClient(cfg.ResendAPIKey, cfg.EmailFrom)
+	if cfg.SMTPHost != "" {
+		emailClient = infrastructure.NewSMTPEmailClient(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom)
+	}
 
 	// Auto Migrate
-	if err := db.AutoMigrate(&models.User{}, &models.Task{}, &models.Log{}); err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+	if err := db.AutoMigrate(&models.User{}, &models.Task{}, &models.Log{}, &models.Incident{}, &models.Channel{}, &models.HourlyStat{}, &models.DailyStat{}, &models.APIKey{}, &models.RefreshToken{}, &models.Organization{}, &models.Membership{}, &models.Invitation{}, &models.Plan{}, &models.StatusPage{}, &models.StatusPageMonitor{}, &models.Announcement{}, &models.AnnouncementUpdate{}, &models.StatusSubscriber{}); err != nil {
+		fatal("Failed to migrate database", err)
 	}
 
 	// 3. Repository Layer
 	taskRepo := repository.NewTaskRepository(db)
 	logRepo := repository.NewLogRepository(db)
+	incidentRepo := repository.NewIncidentRepository(db)
+	channelRepo := repository.NewChannelRepository(db)
+	statRepo := repository.NewStatRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	orgRepo := repository.NewOrganizationRepository(db)
+	planRepo := repository.NewPlanRepository(db)
+	statusPageRepo := repository.NewStatusPageRepository(db)
+	announcementRepo := repository.NewAnnouncementRepository(db)
 
 	// 4. Service Layer
-	pingService := service.NewPingService(taskRepo, redisClient)
-	authService := service.NewAuthService(taskRepo, cfg.JWTSecret)
+	googleVerifier := oidc.NewGoogleVerifier(cfg.GoogleJWKSURL, cfg.GoogleClientID)
+	var loginProviders []service.LoginProvider
+	for _, p := range cfg.OIDCProviders {
+		loginProviders = append(loginProviders, oidc.NewProvider(oidc.ProviderConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  cfg.PublicURL + "/auth/oidc/" + p.Name + "/callback",
+			Scopes:       p.Scopes,
+		}))
+	}
+	planService := service.NewPlanService(planRepo, taskRepo, cfg.DefaultPlan, cfg.LogRetentionDays)
+	if err := planService.EnsureDefaults(); err != nil {
+		fatal("Failed to set up plans", err)
+	}
+	pingService := service.NewPingService(taskRepo, logRepo, incidentRepo, planService, redisClient)
+	authService := service.NewAuthService(taskRepo, refreshTokenRepo, redisClient, emailClient, googleVerifier, loginProviders, service.AuthConfig{
+		JWTSecret:       cfg.JWTSecret,
+		AccessTokenTTL:  cfg.AccessTokenTTL,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+		PublicURL:       cfg.PublicURL,
+	})
+	statsService := service.NewStatsService(taskRepo, statRepo, incidentRepo)
+	channelService := service.NewChannelService(channelRepo, taskRepo, planService, emailClient, cfg.DashboardURL)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, taskRepo)
+	statusPageService := service.NewStatusPageService(statusPageRepo, taskRepo, statRepo, incidentRepo, announcementRepo)
+	streamService := service.NewStreamService(redisClient)
+	badgeService := service.NewBadgeService(taskRepo, statRepo, incidentRepo, cfg.PublicURL)
+	announcementService := service.NewAnnouncementService(announcementRepo, statusPageRepo, redisClient, emailClient, cfg.PublicURL)
+	orgService := service.NewOrganizationService(orgRepo, taskRepo, emailClient, cfg.DashboardURL)
+
+	// Move tasks and channels created before organizations existed into
+	// their creators' personal organizations.
+	if err := orgService.MigrateOwnership(); err != nil {
+		fatal("Failed to migrate task ownership", err)
+	}
 
 	// 5. Handler Layer
 	pingHandler := handlers.NewPingHandler(pingService)
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, cfg.LoginRedirectURL)
+	channelHandler := handlers.NewChannelHandler(channelService)
+	statsHandler := handlers.NewStatsHandler(statsService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	planHandler := handlers.NewPlanHandler(planService)
+	statusPageHandler := handlers.NewStatusPageHandler(statusPageService)
+	announcementHandler := handlers.NewAnnouncementHandler(announcementService)
+	badgeHandler := handlers.NewBadgeHandler(badgeService)
+	streamHandler := handlers.NewStreamHandler(streamService)
+	probeHandler := handlers.NewProbeHandler()
 
 	// 6. Workers
-	pingWorker := worker.NewPingWorker(redisClient, taskRepo, logRepo, db)
-	notiWorker := worker.NewNotificationWorker(redisClient, taskRepo, emailClient)
+	pingWorker := worker.NewPingWorker(redisClient, taskRepo, logRepo, incidentRepo, planService)
+	notiWorker := worker.NewNotificationWorker(redisClient, taskRepo, channelRepo, incidentRepo, statusPageRepo, announcementRepo, emailClient, cfg.DashboardURL, cfg.PublicURL)
+	compactor := worker.NewCompactor(logRepo, statRepo, planRepo, planService, cfg.LogRetentionDays, cfg.HourlyRetentionDays)
 
 	go pingWorker.Start()
 	go notiWorker.Start()
+	go compactor.Start()
 
 	// 7. Router Setup
-	r := gin.Default()
+	r := gin.New()
+	// The request logger runs inside the tracing middleware so its lines
+	// carry the trace ID, and recovery inside both so a panic is logged
+	// and traced as a 500.
+	r.Use(middleware.RequestID())
+	r.Use(otelgin.Middleware(cfg.TracingServiceName, otelgin.WithFilter(func(req *http.Request) bool {
+		return req.URL.Path != "/metrics"
+	})))
+	r.Use(middleware.RequestLogger())
+	r.Use(middleware.Recovery())
+	r.Use(metrics.GinMiddleware())
 
 	// Public Routes
+	r.GET("/metrics", middleware.MetricsAuth(cfg.MetricsToken), gin.WrapH(promhttp.Handler()))
+	// Per-monitor gauges and /probe, which fetches arbitrary targets, are
+	// only served behind the metrics token.
+	if cfg.MetricsToken != "" {
+		monitorMetrics := prometheus.NewRegistry()
+		monitorMetrics.MustRegister(metrics.NewMonitorCollector(taskRepo, logRepo, incidentRepo))
+		r.GET("/metrics/monitors", middleware.MetricsAuth(cfg.MetricsToken), gin.WrapH(promhttp.HandlerFor(monitorMetrics, promhttp.HandlerOpts{})))
+		r.GET("/probe", middleware.MetricsAuth(cfg.MetricsToken), probeHandler.Probe)
+	}
 	r.POST("/auth/google", authHandler.GoogleLogin)
+	r.POST("/auth/refresh", authHandler.Refresh)
+	r.GET("/auth/providers", authHandler.ListProviders)
+	r.POST("/auth/magic-link", authHandler.RequestMagicLink)
+	r.GET("/auth/magic-link/verify", authHandler.ConfirmMagicLink)
+	r.POST("/auth/magic-link/verify", authHandler.VerifyMagicLink)
+	r.GET("/auth/oidc/:provider/login", authHandler.OIDCLogin)
+	r.GET("/auth/oidc/:provider/callback", authHandler.OIDCCallback)
+	r.GET("/status/:slug", statusPageHandler.ShowStatusPage)
+	r.GET("/status/:slug/feed.atom", announcementHandler.Feed)
+	r.POST("/status/:slug/subscribe", announcementHandler.Subscribe)
+	r.GET("/status/:slug/confirm", announcementHandler.ConfirmSubscription)
+	r.GET("/status/:slug/unsubscribe", announcementHandler.Unsubscribe)
+	r.GET("/badge/:token/status.svg", badgeHandler.StatusBadge)
+	r.GET("/badge/:token/uptime.svg", badgeHandler.UptimeBadge)
 
 	// Protected Routes
 	api := r.Group("/api")
-	api.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	api.Use(middleware.AuthMiddleware(authService, apiKeyService))
+
+	// Monitors and channels belong to the organization picked by the
+	// X-Organization-ID header, defaulting to the personal one.
+	orgScoped := api.Group("", middleware.OrgContext(orgService))
+
+	read := orgScoped.Group("", middleware.RequireScope(models.ScopeRead), middleware.RequireRole(models.RoleViewer))
 	{
-		api.POST("/ping", pingHandler.CreatePing)
+		read.GET("/ping", pingHandler.ListPings)
+		read.GET("/ping/:id", pingHandler.GetPing)
+		read.GET("/ping/:id/logs", pingHandler.ListLogs)
+		read.GET("/ping/:id/stats", statsHandler.GetStats)
+		read.GET("/ping/:id/badge", badgeHandler.GetBadgeLinks)
+		read.GET("/stream", streamHandler.Stream)
+		read.GET("/channels", channelHandler.ListChannels)
+		read.GET("/me/usage", planHandler.GetUsage)
+		read.GET("/status-pages", statusPageHandler.ListStatusPages)
+		read.GET("/status-pages/:id", statusPageHandler.GetStatusPage)
+		read.GET("/status-pages/:id/announcements", announcementHandler.ListAnnouncements)
+		read.GET("/status-pages/:id/subscribers", announcementHandler.ListSubscribers)
+	}
+
+	monitors := orgScoped.Group("", middleware.RequireScope(models.ScopeMonitorsWrite), middleware.RequireRole(models.RoleEditor))
+	{
+		monitors.POST("/ping", pingHandler.CreatePing)
+		monitors.PATCH("/ping/:id", pingHandler.UpdatePing)
+		monitors.DELETE("/ping/:id", pingHandler.DeletePing)
+		monitors.POST("/ping/:id/reactivate", pingHandler.ReactivatePing)
+		monitors.POST("/ping/:id/test-notification", pingHandler.TestNotification)
+		monitors.POST("/ping/:id/badge/rotate", badgeHandler.RotateBadgeToken)
+		monitors.POST("/status-pages", statusPageHandler.CreateStatusPage)
+		monitors.PUT("/status-pages/:id", statusPageHandler.UpdateStatusPage)
+		monitors.DELETE("/status-pages/:id", statusPageHandler.DeleteStatusPage)
+		monitors.POST("/status-pages/:id/announcements", announcementHandler.CreateAnnouncement)
+		monitors.POST("/status-pages/:id/announcements/:announcementId/updates", announcementHandler.PostUpdate)
+		monitors.DELETE("/status-pages/:id/announcements/:announcementId", announcementHandler.DeleteAnnouncement)
+		monitors.DELETE("/status-pages/:id/subscribers/:subscriberId", announcementHandler.RemoveSubscriber)
+	}
+
+	channels := orgScoped.Group("", middleware.RequireScope(models.ScopeChannelsWrite), middleware.RequireRole(models.RoleEditor))
+	{
+		channels.POST("/channels", channelHandler.CreateChannel)
+		channels.POST("/channels/preview", channelHandler.PreviewTemplate)
+		channels.PUT("/channels/:id", channelHandler.UpdateChannel)
+		channels.DELETE("/channels/:id", channelHandler.DeleteChannel)
+		channels.POST("/channels/:id/test", channelHandler.TestChannel)
+	}
+
+	// Sessions and API keys can only be managed from a login session,
+	// never with a key.
+	session := api.Group("", middleware.RequireScope(models.ScopeAll))
+	{
+		session.POST("/auth/logout", authHandler.Logout)
+		session.POST("/orgs", orgHandler.CreateOrganization)
+		session.POST("/invitations/accept", orgHandler.AcceptInvitation)
+	}
+
+	api.GET("/orgs", middleware.RequireScope(models.ScopeRead), orgHandler.ListOrganizations)
+
+	// Membership management is limited to login sessions; RemoveMember
+	// also lets any member leave.
+	org := api.Group("/orgs/:orgId", middleware.OrgContext(orgService))
+	{
+		org.GET("/members", middleware.RequireScope(models.ScopeRead), middleware.RequireRole(models.RoleViewer), orgHandler.ListMembers)
+		org.GET("/invitations", middleware.RequireScope(models.ScopeRead), middleware.RequireRole(models.RoleAdmin), orgHandler.ListInvitations)
+
+		manage := org.Group("", middleware.RequireScope(models.ScopeAll))
+		manage.PATCH("/members/:userId", middleware.RequireRole(models.RoleAdmin), orgHandler.UpdateMember)
+		manage.DELETE("/members/:userId", middleware.RequireRole(models.RoleViewer), orgHandler.RemoveMember)
+		manage.POST("/invitations", middleware.RequireRole(models.RoleAdmin), orgHandler.InviteMember)
+		manage.DELETE("/invitations/:id", middleware.RequireRole(models.RoleAdmin), orgHandler.RevokeInvitation)
+	}
+
+	keys := api.Group("/keys", middleware.RequireScope(models.ScopeAll))
+	{
+		keys.GET("", apiKeyHandler.ListAPIKeys)
+		keys.POST("", apiKeyHandler.CreateAPIKey)
+		keys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
 	}
 
 	// 8. Start Server
-	log.Printf("Server starting on port %s", cfg.Port)
+	slog.Info("Server starting", "port", cfg.Port)
 	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		fatal("Failed to start server", err)
 	}
 }
+
+// fatal logs err and exits, for failures during startup.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
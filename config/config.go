@@ -2,11 +2,25 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// OIDCProvider is configured from OIDC_<NAME>_* variables for each name
+// listed in OIDC_PROVIDERS.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+}
+
 type Config struct {
 	Port           string
 	DatabaseURL    string
@@ -14,6 +28,50 @@ type Config struct {
 	ResendAPIKey   string
 	JWTSecret      string
 	GoogleClientID string
+	GoogleJWKSURL  string
+	DashboardURL   string
+
+	// When SMTPHost is set, mail goes through SMTP instead of Resend.
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+
+	// PublicURL is where this server is reachable; OIDC callbacks are
+	// registered under it.
+	PublicURL string
+	// OIDCProviders are the generic OpenID Connect login providers, and
+	// LoginRedirectURL, when set, is where the browser is sent after an OIDC
+	// or magic-link login with the tokens in the URL fragment.
+	OIDCProviders    []OIDCProvider
+	LoginRedirectURL string
+
+	// Lifetimes of session access and refresh tokens.
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// DefaultPlan names the plan of users and organizations without one.
+	DefaultPlan string
+
+	// Retention of raw check logs and hourly rollups, in days.
+	LogRetentionDays    int
+	HourlyRetentionDays int
+
+	// MetricsToken protects /metrics when set, and enables the per-monitor
+	// gauges on /metrics/monitors and the /probe endpoint.
+	MetricsToken string
+
+	// TracingExporter is "none", "stdout" or "otlp". TracingEndpoint is the
+	// full OTLP/HTTP traces URL; when empty the exporter follows the
+	// standard OTEL_EXPORTER_OTLP_* variables.
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingServiceName string
+	TracingSampleRatio float64
+
+	// LogLevel is the minimum level written to the JSON log.
+	LogLevel slog.Level
 }
 
 func LoadConfig() (*Config, error) {
@@ -25,20 +83,134 @@ func LoadConfig() (*Config, error) {
 		DatabaseURL:    getEnv("DATABASE_URL", ""),
 		RedisAddr:      getEnv("REDIS_ADDR", "localhost:6379"),
 		ResendAPIKey:   getEnv("RESEND_API_KEY", ""),
+		SMTPHost:       getEnv("SMTP_HOST", ""),
+		SMTPPort:       getEnv("SMTP_PORT", "1025"),
+		SMTPUsername:   getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:   getEnv("SMTP_PASSWORD", ""),
+		EmailFrom:      getEnv("EMAIL_FROM", "onboarding@resend.dev"),
 		JWTSecret:      getEnv("JWT_SECRET", "secret"),
 		GoogleClientID: getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleJWKSURL:  getEnv("GOOGLE_JWKS_URL", "https://www.googleapis.com/oauth2/v3/certs"),
+		DashboardURL:   getEnv("DASHBOARD_URL", "https://upbot.vineet.tech/dashboard"),
+
+		PublicURL:        strings.TrimSuffix(getEnv("PUBLIC_URL", "http://localhost:8080"), "/"),
+		LoginRedirectURL: getEnv("LOGIN_REDIRECT_URL", ""),
+
+		DefaultPlan: getEnv("DEFAULT_PLAN", "free"),
+
+		MetricsToken: getEnv("METRICS_TOKEN", ""),
+
+		TracingExporter:    getEnv("TRACING_EXPORTER", "none"),
+		TracingEndpoint:    getEnv("TRACING_OTLP_ENDPOINT", ""),
+		TracingServiceName: getEnv("OTEL_SERVICE_NAME", "upbot-server"),
 	}
 
 	if config.DatabaseURL == "" {
 		return nil, fmt.Errorf("DATABASE_URL is required")
 	}
 
+	accessMinutes, err := getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15)
+	if err != nil {
+		return nil, err
+	}
+	refreshDays, err := getEnvInt("REFRESH_TOKEN_TTL_DAYS", 30)
+	if err != nil {
+		return nil, err
+	}
+	if accessMinutes < 1 || refreshDays < 1 {
+		return nil, fmt.Errorf("ACCESS_TOKEN_TTL_MINUTES and REFRESH_TOKEN_TTL_DAYS must be positive")
+	}
+	config.AccessTokenTTL = time.Duration(accessMinutes) * time.Minute
+	config.RefreshTokenTTL = time.Duration(refreshDays) * 24 * time.Hour
+
+	if config.LogRetentionDays, err = getEnvInt("LOG_RETENTION_DAYS", 7); err != nil {
+		return nil, err
+	}
+	if config.HourlyRetentionDays, err = getEnvInt("HOURLY_RETENTION_DAYS", 30); err != nil {
+		return nil, err
+	}
+	// Raw logs must outlive the daily rollup period and hourly rows must
+	// cover the 7 day stats window.
+	if config.LogRetentionDays < 2 {
+		return nil, fmt.Errorf("LOG_RETENTION_DAYS must be at least 2")
+	}
+	if config.HourlyRetentionDays < 8 {
+		return nil, fmt.Errorf("HOURLY_RETENTION_DAYS must be at least 8")
+	}
+
+	switch config.TracingExporter {
+	case "none", "stdout", "otlp":
+	default:
+		return nil, fmt.Errorf("TRACING_EXPORTER must be none, stdout or otlp")
+	}
+	if config.TracingSampleRatio, err = getEnvFloat("TRACING_SAMPLE_RATIO", 1); err != nil {
+		return nil, err
+	}
+	if config.TracingSampleRatio < 0 || config.TracingSampleRatio > 1 {
+		return nil, fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1")
+	}
+
+	if err := config.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error")
+	}
+
+	if config.OIDCProviders, err = loadOIDCProviders(); err != nil {
+		return nil, err
+	}
+
 	return config, nil
 }
 
+func loadOIDCProviders() ([]OIDCProvider, error) {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "")),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("%sISSUER and %sCLIENT_ID are required", prefix, prefix)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value, exists := os.LookupEnv(key)
+	if !exists || value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number: %w", key, err)
+	}
+	return f, nil
+}
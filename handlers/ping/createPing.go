@@ -3,6 +3,7 @@ package ping
 import (
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 	"upbot-server-go/database"
 	"upbot-server-go/libraries"
@@ -46,10 +47,10 @@ func CreatePingHandler(c *gin.Context) {
 		return
 	}
 
-	if len(user.Tasks) >= 5 {
+	if limit := activeTaskLimit(); limit > 0 && activeTaskCount(user.Tasks) >= limit {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Task limit reached",
-			"details": "You can only have 5 active tasks at a time",
+			"details": fmt.Sprintf("You can only have %d active tasks at a time", limit),
 		})
 		return
 	}
@@ -100,3 +101,29 @@ func CreatePingHandler(c *gin.Context) {
 		"taskId":  newTask.ID,
 	})
 }
+
+// activeTaskLimit returns the monitor limit of the default plan (DEFAULT_PLAN,
+// "free" unless set) from the plans table kept by cmd/server; zero means
+// unlimited. Databases without plans keep the original limit of 5.
+func activeTaskLimit() int {
+	planName := os.Getenv("DEFAULT_PLAN")
+	if planName == "" {
+		planName = "free"
+	}
+	var limits []int
+	err := database.DB.Table("plans").Where("name = ? AND deleted_at IS NULL", planName).Pluck("max_monitors", &limits).Error
+	if err != nil || len(limits) == 0 {
+		return 5
+	}
+	return limits[0]
+}
+
+func activeTaskCount(tasks []models.Task) int {
+	count := 0
+	for _, task := range tasks {
+		if task.IsActive {
+			count++
+		}
+	}
+	return count
+}
@@ -56,6 +56,14 @@ func ReactivatePingHandler(c *gin.Context) {
 		return
 	}
 
+	if limit := activeTaskLimit(); !task.IsActive && limit > 0 && activeTaskCount(user.Tasks) >= limit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Task limit reached",
+			"details": fmt.Sprintf("You can only have %d active tasks at a time", limit),
+		})
+		return
+	}
+
 	task.IsActive = true
 	if err := database.DB.Save(&task).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnnouncementHandler struct {
+	service service.AnnouncementService
+}
+
+func NewAnnouncementHandler(service service.AnnouncementService) *AnnouncementHandler {
+	return &AnnouncementHandler{service: service}
+}
+
+type AnnouncementRequest struct {
+	Kind           string     `json:"kind" binding:"required"`
+	Title          string     `json:"title" binding:"required"`
+	Status         string     `json:"status"`
+	Message        string     `json:"message"`
+	ScheduledStart *time.Time `json:"scheduledStart"`
+	ScheduledEnd   *time.Time `json:"scheduledEnd"`
+}
+
+type AnnouncementUpdateRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message" binding:"required"`
+}
+
+// SubscribeRequest is accepted as JSON or as a form post from the status
+// page.
+type SubscribeRequest struct {
+	Email      string `json:"email" form:"email"`
+	WebhookURL string `json:"webhookUrl" form:"webhookUrl"`
+}
+
+func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
+	membership, ok := currentMembership(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+	_, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+
+	var req AnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	announcement, err := h.service.CreateAnnouncement(membership.OrganizationID, pageID, membership.UserID, service.AnnouncementRequest{
+		Kind:           req.Kind,
+		Title:          req.Title,
+		Status:         req.Status,
+		Message:        req.Message,
+		ScheduledStart: req.ScheduledStart,
+		ScheduledEnd:   req.ScheduledEnd,
+	})
+	if err != nil {
+		announcementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Announcement posted successfully",
+		"announcement": announcement,
+	})
+}
+
+func (h *AnnouncementHandler) ListAnnouncements(c *gin.Context) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+
+	announcements, err := h.service.ListAnnouncements(orgID, pageID)
+	if err != nil {
+		announcementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"announcements": announcements})
+}
+
+// PostUpdate adds an update to an announcement and notifies subscribers.
+func (h *AnnouncementHandler) PostUpdate(c *gin.Context) {
+	membership, ok := currentMembership(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+	_, pageID, announcementID, ok := announcementParams(c)
+	if !ok {
+		return
+	}
+
+	var req AnnouncementUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	announcement, err := h.service.PostUpdate(membership.OrganizationID, pageID, announcementID, membership.UserID, service.AnnouncementUpdateRequest{
+		Status:  req.Status,
+		Message: req.Message,
+	})
+	if err != nil {
+		announcementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Update posted successfully",
+		"announcement": announcement,
+	})
+}
+
+func (h *AnnouncementHandler) DeleteAnnouncement(c *gin.Context) {
+	orgID, pageID, announcementID, ok := announcementParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAnnouncement(orgID, pageID, announcementID); err != nil {
+		announcementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Announcement deleted successfully",
+		"announcementId": announcementID,
+	})
+}
+
+func (h *AnnouncementHandler) ListSubscribers(c *gin.Context) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+
+	subscribers, err := h.service.ListSubscribers(orgID, pageID)
+	if err != nil {
+		announcementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscribers": subscribers})
+}
+
+func (h *AnnouncementHandler) RemoveSubscriber(c *gin.Context) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+	subscriberID, err := strconv.ParseUint(c.Param("subscriberId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscriber ID"})
+		return
+	}
+
+	if err := h.service.RemoveSubscriber(orgID, pageID, uint(subscriberID)); err != nil {
+		announcementError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Subscriber removed successfully",
+		"subscriberId": subscriberID,
+	})
+}
+
+// Subscribe signs a visitor up for a status page's updates. Email addresses
+// get a confirmation link first.
+func (h *AnnouncementHandler) Subscribe(c *gin.Context) {
+	var req SubscribeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		publicResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	subscriber, err := h.service.Subscribe(c.Request.Context(), c.Param("slug"), service.SubscribeRequest{
+		Email:      req.Email,
+		WebhookURL: req.WebhookURL,
+	})
+	if err != nil {
+		publicResponse(c, publicErrorStatus(err), err.Error())
+		return
+	}
+
+	if subscriber.WebhookURL != "" {
+		// The token is the only way to cancel a webhook subscription.
+		c.JSON(http.StatusCreated, gin.H{
+			"message":        "Webhook subscribed successfully",
+			"unsubscribeUrl": "/status/" + c.Param("slug") + "/unsubscribe?token=" + subscriber.Token,
+		})
+		return
+	}
+	publicResponse(c, http.StatusAccepted, "Check your inbox to confirm the subscription")
+}
+
+func (h *AnnouncementHandler) ConfirmSubscription(c *gin.Context) {
+	if err := h.service.ConfirmSubscription(c.Param("slug"), c.Query("token")); err != nil {
+		publicResponse(c, publicErrorStatus(err), err.Error())
+		return
+	}
+	publicResponse(c, http.StatusOK, "Subscription confirmed")
+}
+
+func (h *AnnouncementHandler) Unsubscribe(c *gin.Context) {
+	if err := h.service.Unsubscribe(c.Param("slug"), c.Query("token")); err != nil {
+		publicResponse(c, publicErrorStatus(err), err.Error())
+		return
+	}
+	publicResponse(c, http.StatusOK, "You have been unsubscribed")
+}
+
+// Feed serves the status page's updates as an Atom feed.
+func (h *AnnouncementHandler) Feed(c *gin.Context) {
+	feed, err := h.service.Feed(c.Param("slug"))
+	if err != nil {
+		c.String(publicErrorStatus(err), err.Error())
+		return
+	}
+
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "failed to render feed")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	c.Data(http.StatusOK, "application/atom+xml; charset=utf-8", append([]byte(xml.Header), body...))
+}
+
+// announcementParams reads the organization, :id and :announcementId,
+// writing the error response itself when any is missing.
+func announcementParams(c *gin.Context) (uint, uint, uint, bool) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	announcementID, err := strconv.ParseUint(c.Param("announcementId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid announcement ID"})
+		return 0, 0, 0, false
+	}
+	return orgID, pageID, uint(announcementID), true
+}
+
+func announcementError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrStatusPageNotFound),
+		errors.Is(err, service.ErrAnnouncementNotFound),
+		errors.Is(err, service.ErrSubscriberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func publicErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrStatusPageNotFound), errors.Is(err, service.ErrSubscriberNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrSubscribeThrottle):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// publicResponse answers visitors of a status page with JSON for API
+// clients and plain text for browsers following a form or email link.
+func publicResponse(c *gin.Context, status int, message string) {
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		key := "message"
+		if status >= http.StatusBadRequest {
+			key = "error"
+		}
+		c.JSON(status, gin.H{key: message})
+		return
+	}
+	c.String(status, message)
+}
@@ -1,21 +1,39 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/subtle"
+	"errors"
+	"html/template"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"upbot-server-go/internal/models"
 	"upbot-server-go/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	service service.AuthService
+	service          service.AuthService
+	loginRedirectURL string
 }
 
-func NewAuthHandler(service service.AuthService) *AuthHandler {
-	return &AuthHandler{service: service}
+func NewAuthHandler(service service.AuthService, loginRedirectURL string) *AuthHandler {
+	return &AuthHandler{service: service, loginRedirectURL: loginRedirectURL}
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	All          bool   `json:"all"`
+}
+
+// GoogleLogin expects a Google ID token as the bearer token.
 func (h *AuthHandler) GoogleLogin(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
@@ -28,17 +46,229 @@ func (h *AuthHandler) GoogleLogin(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
 		return
 	}
-	accessToken := parts[1]
+	idToken := parts[1]
 
-	user, token, err := h.service.LoginWithGoogle(accessToken)
+	user, tokens, err := h.service.LoginWithGoogle(c.Request.Context(), idToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Auth successful",
-		"token":   token,
-		"user":    user,
+		"message":      "Auth successful",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
+	})
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented
+// refresh token stops working.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Refresh(req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Token refreshed",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+	})
+}
+
+// Logout revokes the current access token and its refresh token, or every
+// session of the user when "all" is set.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	val, _ := c.Get("claims")
+	claims, ok := val.(*service.AccessClaims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := h.service.Logout(claims, req.RefreshToken, req.All)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// ListProviders returns the names of the configured OIDC login providers.
+func (h *AuthHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.service.ListProviders()})
+}
+
+// oidcStateCookie binds a login to the browser that started it. Without it
+// an attacker could send a victim the callback URL of the attacker's own
+// login and sign them in to the attacker's account.
+const oidcStateCookie = "upbot_oidc_state"
+
+// OIDCLogin redirects the browser to the identity provider.
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.service.BeginOIDCLogin(c.Request.Context(), c.Param("provider"))
+	if errors.Is(err, service.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(service.LoginStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback finishes a login at an OIDC provider. The state must match
+// the cookie set by OIDCLogin in the same browser.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	bound, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	if errParam := c.Query("error"); errParam != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errParam, "description": c.Query("error_description")})
+		return
+	}
+	state := c.Query("state")
+	if bound == "" || subtle.ConstantTimeCompare([]byte(bound), []byte(state)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": service.ErrInvalidState.Error()})
+		return
+	}
+
+	user, tokens, err := h.service.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), state, c.Query("code"))
+	if errors.Is(err, service.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.loginSuccess(c, user, tokens)
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// RequestMagicLink emails a one-time login link.
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.service.SendMagicLink(c.Request.Context(), req.Email)
+	if errors.Is(err, service.ErrMagicLinkThrottle) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Login link sent"})
+}
+
+var magicLinkTemplate = template.Must(template.ParseFS(templateFS, "templates/magic_link.html"))
+
+// ConfirmMagicLink is the target of the emailed link. It only shows a page
+// that posts the token back: mail scanners and link previews follow GET
+// links, and would otherwise use up the single-use link or sign in
+// themselves.
+func (h *AuthHandler) ConfirmMagicLink(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.String(http.StatusBadRequest, "Login link is missing its token")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := magicLinkTemplate.Execute(&buf, token); err != nil {
+		c.String(http.StatusInternalServerError, "failed to render login page")
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("X-Frame-Options", "DENY")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// VerifyMagicLink redeems the token posted by the ConfirmMagicLink page.
+func (h *AuthHandler) VerifyMagicLink(c *gin.Context) {
+	user, tokens, err := h.service.VerifyMagicLink(c.Request.Context(), c.PostForm("token"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.loginSuccess(c, user, tokens)
+}
+
+// loginSuccess finishes a browser login. With a login redirect URL
+// configured the browser is sent there with the tokens in the fragment,
+// otherwise the tokens are returned as JSON.
+func (h *AuthHandler) loginSuccess(c *gin.Context, user *models.User, tokens *service.TokenPair) {
+	if h.loginRedirectURL != "" {
+		fragment := url.Values{
+			"token":        {tokens.AccessToken},
+			"refreshToken": {tokens.RefreshToken},
+			"expiresIn":    {strconv.FormatInt(tokens.ExpiresIn, 10)},
+		}
+		c.Redirect(http.StatusFound, h.loginRedirectURL+"#"+fragment.Encode())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Auth successful",
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    tokens.ExpiresIn,
+		"user":         user,
+	})
+}
+
+// setOIDCStateCookie sets, or with a negative maxAge clears, the login
+// binding cookie. Lax lets it through on the provider's redirect back.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/auth/oidc/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
 	})
 }
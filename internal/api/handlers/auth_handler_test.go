@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAuthService records OIDC and magic-link calls; other methods are
+// not used.
+type fakeAuthService struct {
+	service.AuthService
+	completed bool
+	redeemed  string
+}
+
+func (f *fakeAuthService) BeginOIDCLogin(ctx context.Context, provider string) (string, string, error) {
+	return "https://idp.example.com/authorize?state=s1", "s1", nil
+}
+
+func (f *fakeAuthService) CompleteOIDCLogin(ctx context.Context, provider, state, code string) (*models.User, *service.TokenPair, error) {
+	f.completed = true
+	return &models.User{Email: "a@example.com"}, &service.TokenPair{AccessToken: "access"}, nil
+}
+
+func (f *fakeAuthService) VerifyMagicLink(ctx context.Context, token string) (*models.User, *service.TokenPair, error) {
+	f.redeemed = token
+	return &models.User{Email: "a@example.com"}, &service.TokenPair{AccessToken: "access"}, nil
+}
+
+func newAuthRouter(svc service.AuthService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewAuthHandler(svc, "")
+	r := gin.New()
+	r.GET("/auth/oidc/:provider/login", h.OIDCLogin)
+	r.GET("/auth/oidc/:provider/callback", h.OIDCCallback)
+	r.GET("/auth/magic-link/verify", h.ConfirmMagicLink)
+	r.POST("/auth/magic-link/verify", h.VerifyMagicLink)
+	return r
+}
+
+func TestOIDCLoginSetsStateCookie(t *testing.T) {
+	r := newAuthRouter(&fakeAuthService{})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/oidc/keycloak/login", nil))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	c := cookies[0]
+	if c.Name != oidcStateCookie || c.Value != "s1" || !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("unexpected cookie %+v", c)
+	}
+}
+
+func TestOIDCCallbackState(t *testing.T) {
+	tests := []struct {
+		name       string
+		cookie     string
+		state      string
+		wantStatus int
+	}{
+		{"matching", "s1", "s1", http.StatusOK},
+		{"no cookie", "", "s1", http.StatusUnauthorized},
+		{"other browser's state", "s2", "s1", http.StatusUnauthorized},
+		{"missing state", "s1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeAuthService{}
+			r := newAuthRouter(svc)
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/keycloak/callback?code=c&state="+tt.state, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if svc.completed != (tt.wantStatus == http.StatusOK) {
+				t.Fatalf("CompleteOIDCLogin called = %v", svc.completed)
+			}
+			cleared := false
+			for _, c := range w.Result().Cookies() {
+				if c.Name == oidcStateCookie && c.MaxAge < 0 {
+					cleared = true
+				}
+			}
+			if !cleared {
+				t.Fatal("state cookie was not cleared")
+			}
+		})
+	}
+}
+
+func TestMagicLinkRedeemedOnlyOnPost(t *testing.T) {
+	svc := &fakeAuthService{}
+	r := newAuthRouter(svc)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/auth/magic-link/verify?token=t%22%3E1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if svc.redeemed != "" {
+		t.Fatal("GET redeemed the login link")
+	}
+	if !strings.Contains(w.Body.String(), `value="t&#34;&gt;1"`) {
+		t.Fatalf("confirm page does not carry the escaped token:\n%s", w.Body.String())
+	}
+
+	form := url.Values{"token": {"t\">1"}}
+	req := httptest.NewRequest(http.MethodPost, "/auth/magic-link/verify", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if svc.redeemed != "t\">1" {
+		t.Fatalf("POST redeemed %q", svc.redeemed)
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BadgeHandler struct {
+	service service.BadgeService
+}
+
+func NewBadgeHandler(service service.BadgeService) *BadgeHandler {
+	return &BadgeHandler{service: service}
+}
+
+// GetBadgeLinks returns the embeddable badge URLs for a task, empty until
+// RotateBadgeToken issues the first token.
+func (h *BadgeHandler) GetBadgeLinks(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.service.GetBadgeLinks(orgID, taskID)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+// RotateBadgeToken issues new badge URLs for a task, invalidating any
+// existing ones.
+func (h *BadgeHandler) RotateBadgeToken(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.service.RotateToken(orgID, taskID)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Badge token issued successfully",
+		"badge":   links,
+	})
+}
+
+// StatusBadge serves /badge/:token/status.svg.
+func (h *BadgeHandler) StatusBadge(c *gin.Context) {
+	badge, err := h.service.Status(c.Param("token"))
+	writeBadge(c, "status", badge, err, 60)
+}
+
+// UptimeBadge serves /badge/:token/uptime.svg?period=30d.
+func (h *BadgeHandler) UptimeBadge(c *gin.Context) {
+	badge, err := h.service.Uptime(c.Param("token"), c.Query("period"))
+	writeBadge(c, "uptime", badge, err, 300)
+}
+
+// writeBadge answers errors with a grey badge too, so a broken embed still
+// renders something legible in a README.
+func writeBadge(c *gin.Context, label string, badge *service.Badge, err error, maxAge int) {
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, service.ErrBadgeNotFound):
+		status, badge = http.StatusNotFound, &service.Badge{Label: label, Message: "not found", Color: "grey"}
+	case errors.Is(err, service.ErrInvalidPeriod):
+		status, badge = http.StatusBadRequest, &service.Badge{Label: label, Message: "invalid period", Color: "grey"}
+	case err != nil:
+		status, badge = http.StatusInternalServerError, &service.Badge{Label: label, Message: "error", Color: "grey"}
+	}
+
+	// Image proxies such as GitHub's camo honour these; keep them short so
+	// an outage shows up quickly.
+	if status == http.StatusOK {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, s-maxage=%d", maxAge, maxAge))
+	} else {
+		c.Header("Cache-Control", "no-cache")
+	}
+	c.Data(status, "image/svg+xml; charset=utf-8", renderBadge(badge))
+}
+
+var badgeColors = map[string]string{
+	"green":  "#4c1",
+	"yellow": "#dfb317",
+	"red":    "#e05d44",
+	"grey":   "#9f9f9f",
+}
+
+// renderBadge draws a shields.io "flat" style badge.
+func renderBadge(badge *service.Badge) []byte {
+	color, ok := badgeColors[badge.Color]
+	if !ok {
+		color = badgeColors["grey"]
+	}
+	labelWidth := textWidth(badge.Label) + 10
+	messageWidth := textWidth(badge.Message) + 10
+	width := labelWidth + messageWidth
+	label, message := html.EscapeString(badge.Label), html.EscapeString(badge.Message)
+
+	return []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text><text x="%[7]d" y="14">%[4]s</text>
+<text x="%[8]d" y="15" fill="#010101" fill-opacity=".3">%[5]s</text><text x="%[8]d" y="14">%[5]s</text>
+</g>
+</svg>`, width, labelWidth, messageWidth, label, message, color, labelWidth/2, labelWidth+messageWidth/2))
+}
+
+// textWidth approximates the rendered width of s in 11px Verdana; exact
+// metrics are not needed since the text is centred in its box.
+func textWidth(s string) int {
+	var width float64
+	for _, r := range s {
+		switch {
+		case r == ' ' || r == '.' || r == ':' || r == 'i' || r == 'l' || r == 'j' || r == 'I':
+			width += 3.5
+		case r == 'f' || r == 't' || r == 'r':
+			width += 4.5
+		case r == 'm' || r == 'w' || r == '%' || r == 'M' || r == 'W':
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.5
+		default:
+			width += 6.8
+		}
+	}
+	return int(width + 0.5)
+}
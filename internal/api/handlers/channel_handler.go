@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChannelHandler struct {
+	service service.ChannelService
+}
+
+func NewChannelHandler(service service.ChannelService) *ChannelHandler {
+	return &ChannelHandler{service: service}
+}
+
+type ChannelRequest struct {
+	Name          string            `json:"name" binding:"required"`
+	Type          string            `json:"type" binding:"required"`
+	Config        map[string]string `json:"config"`
+	TitleTemplate string            `json:"titleTemplate"`
+	BodyTemplate  string            `json:"bodyTemplate"`
+	TaskIDs       *[]uint           `json:"taskIds"`
+
+	RateLimitPerMinute int    `json:"rateLimitPerMinute"`
+	GroupWindowSeconds int    `json:"groupWindowSeconds"`
+	DigestMode         string `json:"digestMode"`
+}
+
+func (r ChannelRequest) toService() service.ChannelRequest {
+	return service.ChannelRequest{
+		Name:          r.Name,
+		Type:          r.Type,
+		Config:        r.Config,
+		TitleTemplate: r.TitleTemplate,
+		BodyTemplate:  r.BodyTemplate,
+		TaskIDs:       r.TaskIDs,
+
+		RateLimitPerMinute: r.RateLimitPerMinute,
+		GroupWindowSeconds: r.GroupWindowSeconds,
+		DigestMode:         r.DigestMode,
+	}
+}
+
+type TemplatePreviewRequest struct {
+	TitleTemplate string `json:"titleTemplate"`
+	BodyTemplate  string `json:"bodyTemplate"`
+	Event         string `json:"event"`
+}
+
+func (h *ChannelHandler) CreateChannel(c *gin.Context) {
+	membership, ok := currentMembership(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.service.CreateChannel(membership.OrganizationID, membership.UserID, req.toService())
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Channel created successfully",
+		"channel": channel,
+	})
+}
+
+func (h *ChannelHandler) ListChannels(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	channels, err := h.service.ListChannels(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"channels": channels})
+}
+
+func (h *ChannelHandler) UpdateChannel(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+	channelID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	var req ChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.service.UpdateChannel(orgID, uint(channelID), req.toService())
+	if errors.Is(err, service.ErrChannelNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Channel updated successfully",
+		"channel": channel,
+	})
+}
+
+func (h *ChannelHandler) DeleteChannel(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+	channelID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	err = h.service.DeleteChannel(orgID, uint(channelID))
+	if errors.Is(err, service.ErrChannelNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Channel deleted successfully",
+		"channelId": channelID,
+	})
+}
+
+// PreviewTemplate renders channel templates against sample data.
+func (h *ChannelHandler) PreviewTemplate(c *gin.Context) {
+	var req TemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := h.service.PreviewTemplate(service.TemplatePreviewRequest{
+		TitleTemplate: req.TitleTemplate,
+		BodyTemplate:  req.BodyTemplate,
+		Event:         notifier.EventType(req.Event),
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+// TestChannel delivers a sample alert and reports the result.
+func (h *ChannelHandler) TestChannel(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+	channelID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return
+	}
+
+	delivery, err := h.service.TestChannel(orgID, uint(channelID))
+	if errors.Is(err, service.ErrChannelNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
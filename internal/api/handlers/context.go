@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"upbot-server-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUserID returns the user ID that AuthMiddleware stored on the context.
+func currentUserID(c *gin.Context) (uint, bool) {
+	val, exists := c.Get("userId")
+	if !exists {
+		return 0, false
+	}
+	userID, ok := val.(uint)
+	return userID, ok
+}
+
+// currentMembership returns the membership that OrgContext resolved for the
+// request's organization.
+func currentMembership(c *gin.Context) (*models.Membership, bool) {
+	val, exists := c.Get("membership")
+	if !exists {
+		return nil, false
+	}
+	membership, ok := val.(*models.Membership)
+	return membership, ok
+}
+
+// currentOrgID returns the organization the request acts on.
+func currentOrgID(c *gin.Context) (uint, bool) {
+	membership, ok := currentMembership(c)
+	if !ok {
+		return 0, false
+	}
+	return membership.OrganizationID, true
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 	"upbot-server-go/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -16,8 +19,29 @@ func NewPingHandler(service service.PingService) *PingHandler {
 }
 
 type CreatePingRequest struct {
-	Url     string `json:"url" binding:"required,url"`
-	WebHook string `json:"webHook"`
+	Url      string   `json:"url" binding:"required,url"`
+	WebHook  string   `json:"webHook"`
+	Tags     []string `json:"tags"`
+	Interval int      `json:"interval" binding:"omitempty,min=1"`
+}
+
+type UpdatePingRequest struct {
+	Url      *string  `json:"url" binding:"omitempty,url"`
+	WebHook  *string  `json:"webHook"`
+	IsActive *bool    `json:"isActive"`
+	Tags     []string `json:"tags"`
+	Interval *int     `json:"interval" binding:"omitempty,min=1"`
+}
+
+type ListPingsQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=up down paused"`
+	Tag    string `form:"tag"`
+	Type   string `form:"type"`
+	Query  string `form:"q"`
+	Sort   string `form:"sort" binding:"omitempty,oneof=id url createdAt"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
 }
 
 func (h *PingHandler) CreatePing(c *gin.Context) {
@@ -27,21 +51,21 @@ func (h *PingHandler) CreatePing(c *gin.Context) {
 		return
 	}
 
-	// In a real app, get email from context (set by auth middleware)
-	// email := c.GetString("email")
-	// For now, let's assume a test email or get it from a header for demonstration if auth isn't set up
-	email := "test@example.com" 
-	if val, exists := c.Get("email"); exists {
-		email = val.(string)
+	membership, ok := currentMembership(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
 	}
 
-	task, err := h.service.CreatePing(email, service.CreatePingRequest{
-		URL:     req.Url,
-		WebHook: req.WebHook,
+	task, err := h.service.CreatePing(membership.OrganizationID, membership.UserID, service.CreatePingRequest{
+		URL:             req.Url,
+		WebHook:         req.WebHook,
+		Tags:            req.Tags,
+		IntervalSeconds: req.Interval,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		pingError(c, err)
 		return
 	}
 
@@ -51,3 +75,199 @@ func (h *PingHandler) CreatePing(c *gin.Context) {
 		"url":     task.URL,
 	})
 }
+
+func (h *PingHandler) ListPings(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	var query ListPingsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.ListPings(orgID, service.ListPingsRequest{
+		Status: query.Status,
+		Tag:    query.Tag,
+		Type:   query.Type,
+		Query:  query.Query,
+		Sort:   query.Sort,
+		Desc:   query.Order == "desc",
+		Cursor: query.Cursor,
+		Limit:  query.Limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *PingHandler) GetPing(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.service.GetPing(orgID, taskID)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ping": task})
+}
+
+func (h *PingHandler) UpdatePing(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.service.UpdatePing(orgID, taskID, service.UpdatePingRequest{
+		URL:             req.Url,
+		WebHook:         req.WebHook,
+		IsActive:        req.IsActive,
+		Tags:            req.Tags,
+		IntervalSeconds: req.Interval,
+	})
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task updated successfully",
+		"ping":    task,
+	})
+}
+
+func (h *PingHandler) DeletePing(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePing(orgID, taskID); err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task deleted successfully",
+		"taskId":  taskID,
+	})
+}
+
+func (h *PingHandler) ReactivatePing(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.service.ReactivatePing(orgID, taskID)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Task reactivated successfully",
+		"taskId":  task.ID,
+		"url":     task.URL,
+	})
+}
+
+type ListLogsQuery struct {
+	From   *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Status string     `form:"status" binding:"omitempty,oneof=success failure"`
+	Cursor string     `form:"cursor"`
+	Limit  int        `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ListLogs returns the check history of a task.
+func (h *PingHandler) ListLogs(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	var query ListLogsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := service.ListLogsRequest{
+		From:   query.From,
+		To:     query.To,
+		Cursor: query.Cursor,
+		Limit:  query.Limit,
+	}
+	if query.Status != "" {
+		success := query.Status == "success"
+		req.Success = &success
+	}
+
+	page, err := h.service.ListLogs(orgID, taskID, req)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// pingParams reads the current organization and the :id task parameter,
+// writing the error response itself when either is missing.
+func pingParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return 0, 0, false
+	}
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, 0, false
+	}
+	return orgID, uint(taskID), true
+}
+
+func pingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrTaskNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrQuotaExceeded):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// TestNotification delivers a sample alert to the task's webhook.
+func (h *PingHandler) TestNotification(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.TestNotification(orgID, taskID)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlanHandler struct {
+	service service.PlanService
+}
+
+func NewPlanHandler(service service.PlanService) *PlanHandler {
+	return &PlanHandler{service: service}
+}
+
+// GetUsage returns the plan of the current organization and how much of it
+// is used.
+func (h *PlanHandler) GetUsage(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	usage, err := h.service.Usage(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, usage)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"upbot-server-go/internal/checker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// defaultProbeTimeout applies when Prometheus does not send its scrape
+	// timeout.
+	defaultProbeTimeout = 10 * time.Second
+	maxProbeTimeout     = 60 * time.Second
+)
+
+// probeModules maps the accepted module names to the checker they run.
+// "http_2xx", "tcp_connect" and "dns" are the blackbox exporter's example
+// modules, so existing scrape configs work unchanged.
+var probeModules = map[string]string{
+	"":            "http",
+	"http":        "http",
+	"http_2xx":    "http",
+	"tcp":         "tcp",
+	"tcp_connect": "tcp",
+	"dns":         "dns",
+}
+
+type ProbeHandler struct {
+	client *http.Client
+}
+
+func NewProbeHandler() *ProbeHandler {
+	return &ProbeHandler{client: &http.Client{}}
+}
+
+// Probe checks ?target= once, synchronously, with the checkers the ping
+// worker uses, and answers in the blackbox exporter's metric format. HTTP
+// targets are URLs or hosts, TCP targets host:port pairs and DNS targets
+// names resolved with the server's resolver.
+func (h *ProbeHandler) Probe(c *gin.Context) {
+	module := c.Query("module")
+	prober, ok := probeModules[module]
+	if !ok {
+		c.String(http.StatusBadRequest, "Unknown module %q", module)
+		return
+	}
+	target := c.Query("target")
+	if target == "" {
+		c.String(http.StatusBadRequest, "Target parameter is missing")
+		return
+	}
+	switch prober {
+	case "http":
+		if !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+		if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.String(http.StatusBadRequest, "Target must be an http(s) URL or host")
+			return
+		}
+	case "tcp":
+		if host, port, err := net.SplitHostPort(target); err != nil || host == "" || port == "" {
+			c.String(http.StatusBadRequest, "Target must be a host:port pair")
+			return
+		}
+	case "dns":
+		if strings.ContainsAny(target, "/: ") {
+			c.String(http.StatusBadRequest, "Target must be a host name")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), probeTimeout(c))
+	defer cancel()
+	var result checker.Result
+	switch prober {
+	case "http":
+		result = checker.HTTP(ctx, h.client, target)
+	case "tcp":
+		result = checker.TCP(ctx, target)
+	case "dns":
+		result = checker.DNS(ctx, net.DefaultResolver, target)
+	}
+
+	success := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_success",
+		Help: "Displays whether or not the probe was a success",
+	})
+	duration := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "probe_duration_seconds",
+		Help: "Returns how long the probe took to complete in seconds",
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(success, duration)
+
+	if result.Success {
+		success.Set(1)
+	}
+	duration.Set(result.Duration.Seconds())
+	if prober == "http" {
+		statusCode := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_http_status_code",
+			Help: "Response HTTP status code",
+		})
+		registry.MustRegister(statusCode)
+		statusCode.Set(float64(result.StatusCode))
+	}
+	if result.CertExpiresAt != nil {
+		certExpiry := prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probe_ssl_earliest_cert_expiry",
+			Help: "Returns last SSL chain expiry in unixtime",
+		})
+		registry.MustRegister(certExpiry)
+		certExpiry.Set(float64(result.CertExpiresAt.Unix()))
+	}
+
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+}
+
+// probeTimeout follows the blackbox exporter: use Prometheus' scrape timeout
+// less half a second, so the probe reports a failure before the scrape
+// itself times out.
+func probeTimeout(c *gin.Context) time.Duration {
+	seconds, err := strconv.ParseFloat(c.GetHeader("X-Prometheus-Scrape-Timeout-Seconds"), 64)
+	if err != nil || seconds <= 0 {
+		return defaultProbeTimeout
+	}
+	timeout := time.Duration((seconds - 0.5) * float64(time.Second))
+	if timeout <= 0 {
+		timeout = time.Duration(seconds * float64(time.Second))
+	}
+	if timeout > maxProbeTimeout {
+		timeout = maxProbeTimeout
+	}
+	return timeout
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	service service.StatsService
+}
+
+func NewStatsHandler(service service.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// GetStats returns uptime, incident and latency figures for a task.
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	orgID, taskID, ok := pingParams(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.service.GetStats(orgID, taskID)
+	if err != nil {
+		pingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
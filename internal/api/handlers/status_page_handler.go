@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// defaultAccentColor is used by status pages without their own colour.
+const defaultAccentColor = "#5cb85c"
+
+var statusTemplates = template.Must(template.New("").Funcs(template.FuncMap{
+	"accent": func(color string) string {
+		if color == "" {
+			return defaultAccentColor
+		}
+		return color
+	},
+	"uptime": func(uptime *float64) string {
+		if uptime == nil {
+			return "No data"
+		}
+		return fmt.Sprintf("%.2f%%", *uptime)
+	},
+	"barClass": func(day service.DayStats) string {
+		switch {
+		case day.Uptime == nil:
+			return ""
+		case *day.Uptime >= 99.9:
+			return "ok"
+		case *day.Uptime >= 95:
+			return "warn"
+		default:
+			return "bad"
+		}
+	},
+	"statusText": func(status string) string {
+		switch status {
+		case service.StatusMajorOutage:
+			return "Major outage"
+		case service.StatusPartialOutage:
+			return "Partial outage"
+		case service.StatusMaintenance:
+			return "Under maintenance"
+		default:
+			return "All systems operational"
+		}
+	},
+	"label": notifier.StatusLabel,
+	"stateText": func(state string) string {
+		switch state {
+		case "down":
+			return "Down"
+		case "paused":
+			return "Paused"
+		default:
+			return "Operational"
+		}
+	},
+}).ParseFS(templateFS, "templates/*.html"))
+
+type StatusPageHandler struct {
+	service service.StatusPageService
+}
+
+func NewStatusPageHandler(service service.StatusPageService) *StatusPageHandler {
+	return &StatusPageHandler{service: service}
+}
+
+type StatusPageRequest struct {
+	Slug        string                     `json:"slug" binding:"required"`
+	Title       string                     `json:"title" binding:"required"`
+	Description string                     `json:"description"`
+	LogoURL     string                     `json:"logoUrl"`
+	AccentColor string                     `json:"accentColor"`
+	ShowURLs    bool                       `json:"showUrls"`
+	Monitors    []StatusPageMonitorRequest `json:"monitors" binding:"dive"`
+}
+
+type StatusPageMonitorRequest struct {
+	TaskID uint   `json:"taskId" binding:"required"`
+	Name   string `json:"name"`
+	Group  string `json:"group"`
+}
+
+func (r StatusPageRequest) toService() service.StatusPageRequest {
+	monitors := make([]service.StatusPageMonitorRequest, len(r.Monitors))
+	for i, m := range r.Monitors {
+		monitors[i] = service.StatusPageMonitorRequest{TaskID: m.TaskID, Name: m.Name, Group: m.Group}
+	}
+	return service.StatusPageRequest{
+		Slug:        r.Slug,
+		Title:       r.Title,
+		Description: r.Description,
+		LogoURL:     r.LogoURL,
+		AccentColor: r.AccentColor,
+		ShowURLs:    r.ShowURLs,
+		Monitors:    monitors,
+	}
+}
+
+func (h *StatusPageHandler) CreateStatusPage(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	var req StatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.CreateStatusPage(orgID, req.toService())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Status page created successfully",
+		"statusPage": page,
+	})
+}
+
+func (h *StatusPageHandler) ListStatusPages(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	pages, err := h.service.ListStatusPages(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"statusPages": pages})
+}
+
+func (h *StatusPageHandler) GetStatusPage(c *gin.Context) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+
+	page, err := h.service.GetStatusPage(orgID, pageID)
+	if err != nil {
+		statusPageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *StatusPageHandler) UpdateStatusPage(c *gin.Context) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+
+	var req StatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.UpdateStatusPage(orgID, pageID, req.toService())
+	if err != nil {
+		statusPageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Status page updated successfully",
+		"statusPage": page,
+	})
+}
+
+func (h *StatusPageHandler) DeleteStatusPage(c *gin.Context) {
+	orgID, pageID, ok := statusPageParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteStatusPage(orgID, pageID); err != nil {
+		statusPageError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Status page deleted successfully",
+		"statusPageId": pageID,
+	})
+}
+
+// ShowStatusPage serves a public status page as HTML, or as JSON when asked
+// for with ?format=json or an Accept header.
+func (h *StatusPageHandler) ShowStatusPage(c *gin.Context) {
+	page, err := h.service.PublicStatus(c.Param("slug"))
+	wantsJSON := c.Query("format") == "json" || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrStatusPageNotFound) {
+			status = http.StatusNotFound
+		}
+		if wantsJSON {
+			c.JSON(status, gin.H{"error": err.Error()})
+		} else {
+			c.String(status, err.Error())
+		}
+		return
+	}
+
+	// Status pages get busy during outages; let browsers and proxies absorb
+	// repeated loads.
+	c.Header("Cache-Control", "public, max-age=60")
+	if wantsJSON {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := statusTemplates.ExecuteTemplate(&buf, "status_page.html", page); err != nil {
+		c.String(http.StatusInternalServerError, "failed to render status page")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// statusPageParams reads the organization and the :id parameter, writing the
+// error response itself when either is missing.
+func statusPageParams(c *gin.Context) (uint, uint, bool) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return 0, 0, false
+	}
+	pageID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status page ID"})
+		return 0, 0, false
+	}
+	return orgID, uint(pageID), true
+}
+
+func statusPageError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrStatusPageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
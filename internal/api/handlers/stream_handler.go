@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamHeartbeat keeps idle connections from being closed by proxies.
+const streamHeartbeat = 25 * time.Second
+
+type StreamHandler struct {
+	service service.StreamService
+}
+
+func NewStreamHandler(service service.StreamService) *StreamHandler {
+	return &StreamHandler{service: service}
+}
+
+// Stream pushes probe results ("check" events) and up/down/paused changes
+// ("state" events) for the organization's monitors as Server-Sent Events.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	orgID, ok := currentOrgID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		return
+	}
+
+	updates := h.service.Subscribe(c.Request.Context(), orgID)
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the response.
+	c.Header("X-Accel-Buffering", "no")
+	c.SSEvent("ready", gin.H{"organizationId": orgID})
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-updates:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"time": time.Now()})
+		}
+		return true
+	})
+}
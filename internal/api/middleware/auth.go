@@ -3,12 +3,16 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/service"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware accepts either a session JWT or a personal API key as the
+// bearer token. It sets "userId", "email" and "scopes" on the context, and
+// "claims" for session tokens.
+func AuthMiddleware(auth service.AuthService, apiKeys service.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,26 +29,47 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		}
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		if strings.HasPrefix(tokenString, service.APIKeyPrefix) {
+			key, user, err := apiKeys.Authenticate(tokenString)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+			c.Set("email", user.Email)
+			c.Set("userId", user.ID)
+			c.Set("scopes", []string(key.Scopes))
+			c.Set("apiKeyId", key.ID)
+			c.Next()
+			return
+		}
 
-		if err != nil || !token.Valid {
+		claims, err := auth.ParseAccessToken(c.Request.Context(), tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			c.Set("email", claims["email"])
-			// Ensure userId is float64 (default for JSON numbers) and convert to uint
-			if userIDFloat, ok := claims["userId"].(float64); ok {
-				c.Set("userId", uint(userIDFloat))
-			}
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		c.Set("email", claims.Email)
+		c.Set("userId", claims.UserID)
+		c.Set("scopes", []string{models.ScopeAll})
+		c.Set("claims", claims)
+		c.Next()
+	}
+}
+
+// RequireScope rejects requests whose credentials lack the given scope.
+// Requiring models.ScopeAll restricts a route to login sessions.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, _ := c.Get("scopes")
+		scopes, _ := granted.([]string)
+		if !models.HasScope(scopes, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient scope", "requiredScope": scope})
 			c.Abort()
+			return
 		}
+		c.Next()
 	}
 }
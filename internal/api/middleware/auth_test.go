@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"upbot-server-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withCredentials stands in for AuthMiddleware, setting the scopes it would
+// resolve for a session or API key.
+func withCredentials(scopes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes != nil {
+			c.Set("scopes", scopes)
+		}
+		c.Next()
+	}
+}
+
+func serve(handlers ...gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", append(handlers, func(c *gin.Context) { c.Status(http.StatusOK) })...)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestRequireScope(t *testing.T) {
+	session := []string{models.ScopeAll}
+	readOnly := []string{models.ScopeRead}
+	monitors := []string{models.ScopeMonitorsWrite}
+	channels := []string{models.ScopeChannelsWrite}
+
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     int
+	}{
+		{"session reads", session, models.ScopeRead, http.StatusOK},
+		{"session writes monitors", session, models.ScopeMonitorsWrite, http.StatusOK},
+		{"session manages keys", session, models.ScopeAll, http.StatusOK},
+		{"read key reads", readOnly, models.ScopeRead, http.StatusOK},
+		{"read key cannot write monitors", readOnly, models.ScopeMonitorsWrite, http.StatusForbidden},
+		{"read key cannot write channels", readOnly, models.ScopeChannelsWrite, http.StatusForbidden},
+		{"read key cannot manage keys", readOnly, models.ScopeAll, http.StatusForbidden},
+		{"write scope implies read", monitors, models.ScopeRead, http.StatusOK},
+		{"monitors key writes monitors", monitors, models.ScopeMonitorsWrite, http.StatusOK},
+		{"monitors key cannot write channels", monitors, models.ScopeChannelsWrite, http.StatusForbidden},
+		{"channels key cannot write monitors", channels, models.ScopeMonitorsWrite, http.StatusForbidden},
+		{"any key cannot manage keys", []string{models.ScopeRead, models.ScopeMonitorsWrite, models.ScopeChannelsWrite}, models.ScopeAll, http.StatusForbidden},
+		{"key without scopes reads nothing", []string{}, models.ScopeRead, http.StatusForbidden},
+		{"no credentials", nil, models.ScopeRead, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serve(withCredentials(tt.granted), RequireScope(tt.required)); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OrganizationHeader selects the organization a request acts on. Without it
+// the user's personal organization is used.
+const OrganizationHeader = "X-Organization-ID"
+
+// OrgContext resolves the caller's membership in the organization named by
+// the :orgId route parameter or the X-Organization-ID header and sets it as
+// "membership" on the context. It must run after AuthMiddleware.
+func OrgContext(orgs service.OrganizationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := c.Get("userId")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+			c.Abort()
+			return
+		}
+
+		raw := c.Param("orgId")
+		if raw == "" {
+			raw = c.GetHeader(OrganizationHeader)
+		}
+		var orgID uint64
+		if raw != "" {
+			var err error
+			if orgID, err = strconv.ParseUint(raw, 10, 64); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+				c.Abort()
+				return
+			}
+		}
+
+		membership, err := orgs.ResolveMembership(userID.(uint), uint(orgID))
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+
+		c.Set("membership", membership)
+		c.Next()
+	}
+}
+
+// RequireRole rejects members whose role in the current organization is
+// below role. It must run after OrgContext.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		val, _ := c.Get("membership")
+		membership, ok := val.(*models.Membership)
+		if !ok || !models.RoleAtLeast(membership.Role, role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role", "requiredRole": role})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"upbot-server-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withMembership stands in for OrgContext, setting the membership it would
+// resolve when role is not empty.
+func withMembership(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role != "" {
+			c.Set("membership", &models.Membership{Role: role})
+		}
+		c.Next()
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	roles := []string{models.RoleViewer, models.RoleEditor, models.RoleAdmin, models.RoleOwner}
+	for i, required := range roles {
+		for j, role := range roles {
+			want := http.StatusForbidden
+			if j >= i {
+				want = http.StatusOK
+			}
+			if got := serve(withMembership(role), RequireRole(required)); got != want {
+				t.Errorf("role %s on %s route: status = %d, want %d", role, required, got, want)
+			}
+		}
+	}
+
+	for _, role := range []string{"", "superuser"} {
+		if got := serve(withMembership(role), RequireRole(models.RoleViewer)); got != http.StatusForbidden {
+			t.Errorf("role %q: status = %d, want %d", role, got, http.StatusForbidden)
+		}
+	}
+}
+
+// TestRouteGroupAuthorization mirrors the scope and role pairs of the route
+// groups in cmd/server: both checks must pass.
+func TestRouteGroupAuthorization(t *testing.T) {
+	groups := map[string][2]string{
+		"read":     {models.ScopeRead, models.RoleViewer},
+		"monitors": {models.ScopeMonitorsWrite, models.RoleEditor},
+		"channels": {models.ScopeChannelsWrite, models.RoleEditor},
+	}
+	session := []string{models.ScopeAll}
+	readOnly := []string{models.ScopeRead}
+	channelsKey := []string{models.ScopeChannelsWrite}
+
+	tests := []struct {
+		name   string
+		scopes []string
+		role   string
+		group  string
+		want   int
+	}{
+		{"viewer session reads", session, models.RoleViewer, "read", http.StatusOK},
+		{"viewer session cannot edit monitors", session, models.RoleViewer, "monitors", http.StatusForbidden},
+		{"viewer session cannot edit channels", session, models.RoleViewer, "channels", http.StatusForbidden},
+		{"editor session edits monitors", session, models.RoleEditor, "monitors", http.StatusOK},
+		{"editor session edits channels", session, models.RoleEditor, "channels", http.StatusOK},
+		{"owner read key cannot edit monitors", readOnly, models.RoleOwner, "monitors", http.StatusForbidden},
+		{"owner read key reads", readOnly, models.RoleOwner, "read", http.StatusOK},
+		{"editor channels key edits channels", channelsKey, models.RoleEditor, "channels", http.StatusOK},
+		{"editor channels key cannot edit monitors", channelsKey, models.RoleEditor, "monitors", http.StatusForbidden},
+		{"viewer channels key cannot edit channels", channelsKey, models.RoleViewer, "channels", http.StatusForbidden},
+		{"non-member cannot read", session, "", "read", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := groups[tt.group]
+			got := serve(withCredentials(tt.scopes), withMembership(tt.role), RequireScope(group[0]), RequireRole(group[1]))
+			if got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
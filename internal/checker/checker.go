@@ -0,0 +1,101 @@
+// Package checker runs monitor checks. The ping worker and the /probe
+// endpoint share it so both judge a target the same way.
+package checker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Result is the outcome of one check.
+type Result struct {
+	Success    bool
+	Duration   time.Duration
+	StatusCode int
+	// Message is the log line stored for the check: a fixed text on
+	// success, the transport error or a generic failure otherwise.
+	Message string
+	// CertExpiresAt is the earliest expiry in the certificate chain, for
+	// HTTPS targets that completed a handshake.
+	CertExpiresAt *time.Time
+}
+
+// HTTP requests url with GET and succeeds on a 200 response.
+func HTTP(ctx context.Context, client *http.Client, url string) Result {
+	var result Result
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	resp, err := client.Do(req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	result.StatusCode = resp.StatusCode
+	result.Success = resp.StatusCode == http.StatusOK
+	if result.Success {
+		result.Message = "Successfully pinged"
+	} else {
+		result.Message = "Failed to ping URL"
+	}
+	if resp.TLS != nil {
+		for _, cert := range resp.TLS.PeerCertificates {
+			if result.CertExpiresAt == nil || cert.NotAfter.Before(*result.CertExpiresAt) {
+				notAfter := cert.NotAfter
+				result.CertExpiresAt = &notAfter
+			}
+		}
+	}
+	return result
+}
+
+// TCP opens a TCP connection to address, a host:port pair, and succeeds
+// once the handshake completes.
+func TCP(ctx context.Context, address string) Result {
+	var result Result
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	conn.Close()
+
+	result.Success = true
+	result.Message = "Successfully connected"
+	return result
+}
+
+// DNS resolves host with resolver and succeeds when it has at least one
+// address.
+func DNS(ctx context.Context, resolver *net.Resolver, host string) Result {
+	var result Result
+	start := time.Now()
+	addrs, err := resolver.LookupHost(ctx, host)
+	result.Duration = time.Since(start)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	if len(addrs) == 0 {
+		result.Message = "No addresses found"
+		return result
+	}
+
+	result.Success = true
+	result.Message = fmt.Sprintf("Resolved %d addresses", len(addrs))
+	return result
+}
@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := listener.Addr().String()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+	defer listener.Close()
+
+	tests := []struct {
+		name    string
+		address string
+		want    bool
+	}{
+		{"listening", open, true},
+		{"refused", closedAddr, false},
+		{"missing port", "127.0.0.1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if got := TCP(ctx, tt.address); got.Success != tt.want {
+				t.Fatalf("TCP(%q) = %+v, want success %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if got := DNS(ctx, net.DefaultResolver, "localhost"); !got.Success {
+		t.Fatalf("DNS(localhost) = %+v, want success", got)
+	}
+	if got := DNS(ctx, net.DefaultResolver, "nonexistent.invalid"); got.Success {
+		t.Fatalf("DNS(nonexistent.invalid) = %+v, want failure", got)
+	}
+}
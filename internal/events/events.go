@@ -0,0 +1,49 @@
+// Package events carries live monitor updates from the ping worker to the
+// API replicas over Redis pub/sub.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Channel is the Redis pub/sub channel every replica subscribes to.
+const Channel = "monitor_events"
+
+const (
+	// TypeCheck is published after every probe.
+	TypeCheck = "check"
+	// TypeState is published when a task goes down, comes back up or is
+	// paused by the worker.
+	TypeState = "state"
+)
+
+// MonitorEvent is one live update. Check events fill the probe fields,
+// state events fill Status and, for up and down, IncidentID.
+type MonitorEvent struct {
+	Type           string    `json:"type"`
+	TaskID         uint      `json:"taskId"`
+	OrganizationID uint      `json:"organizationId"`
+	Time           time.Time `json:"time"`
+
+	Success   *bool  `json:"success,omitempty"`
+	RespCode  int    `json:"respCode,omitempty"`
+	LatencyMs *int64 `json:"latencyMs,omitempty"`
+	Message   string `json:"message,omitempty"`
+
+	Status     string `json:"status,omitempty"`
+	IncidentID uint   `json:"incidentId,omitempty"`
+}
+
+// Publish sends the event to every subscribed replica. Nobody listening is
+// not an error.
+func Publish(ctx context.Context, client *redis.Client, event MonitorEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return client.Publish(ctx, Channel, payload).Err()
+}
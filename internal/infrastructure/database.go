@@ -2,15 +2,15 @@ package infrastructure
 
 import (
 	"fmt"
+	"upbot-server-go/internal/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 func NewDatabaseConnection(databaseURL string) (*gorm.DB, error) {
 	db, err := gorm.Open(postgres.Open(databaseURL), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logging.GORM(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
@@ -2,6 +2,10 @@ package infrastructure
 
 import (
 	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
 
 	"github.com/resend/resend-go/v2"
 )
@@ -12,16 +16,19 @@ type EmailClient interface {
 
 type resendClient struct {
 	client *resend.Client
+	from   string
 }
 
-func NewEmailClient(apiKey string) EmailClient {
+// NewEmailClient returns an EmailClient that delivers through Resend from
+// the given sender, which must be on a domain verified there.
+func NewEmailClient(apiKey, from string) EmailClient {
 	client := resend.NewClient(apiKey)
-	return &resendClient{client: client}
+	return &resendClient{client: client, from: from}
 }
 
 func (r *resendClient) SendEmail(to []string, subject, htmlContent string) error {
 	params := &resend.SendEmailRequest{
-		From:    "onboarding@resend.dev", // Configure this in env if needed
+		From:    r.from,
 		To:      to,
 		Subject: subject,
 		Html:    htmlContent,
@@ -33,3 +40,36 @@ func (r *resendClient) SendEmail(to []string, subject, htmlContent string) error
 	}
 	return nil
 }
+
+// smtpClient sends mail through a plain SMTP server, such as the MailHog
+// container used in development.
+type smtpClient struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPEmailClient returns an EmailClient that delivers via SMTP. Auth is
+// only used when a username is given.
+func NewSMTPEmailClient(host, port, username, password, from string) EmailClient {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpClient{addr: net.JoinHostPort(host, port), from: from, auth: auth}
+}
+
+func (s *smtpClient) SendEmail(to []string, subject, htmlContent string) error {
+	var msg strings.Builder
+	msg.WriteString("From: " + s.from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n\r\n")
+	msg.WriteString(htmlContent)
+
+	if err := smtp.SendMail(s.addr, s.auth, s.from, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package logging sets up the JSON slog logger and carries correlation IDs
+// (request, task, job) on contexts, so every line logged with a context
+// can be joined with the others of the same request or job.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup makes a JSON logger on stdout the slog default. Output of the
+// standard log package is routed through it as well.
+func Setup(level slog.Level) {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// NewID returns a random ID for a request or job.
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type attrsKey struct{}
+
+// With returns ctx carrying args, as key-value pairs or slog.Attrs, to be
+// added to every record logged with it.
+func With(ctx context.Context, args ...any) context.Context {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	parent := attrs(ctx)
+	merged := make([]slog.Attr, len(parent), len(parent)+r.NumAttrs())
+	copy(merged, parent)
+	r.Attrs(func(a slog.Attr) bool {
+		merged = append(merged, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+func attrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	a, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return a
+}
+
+// contextHandler adds the attributes set with With and the current trace
+// and span IDs to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(attrs(ctx)...)
+	if ctx != nil {
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
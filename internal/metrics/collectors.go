@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+	"upbot-server-go/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeTimeout bounds the Redis and database reads of a single scrape.
+const scrapeTimeout = 5 * time.Second
+
+var (
+	pingQueueDepth = prometheus.NewDesc("upbot_ping_queue_depth",
+		"Tasks scheduled in ping_queue.", nil, nil)
+	pingQueueDue = prometheus.NewDesc("upbot_ping_queue_due",
+		"Tasks in ping_queue whose check is due.", nil, nil)
+	pingQueueLag = prometheus.NewDesc("upbot_ping_queue_lag_seconds",
+		"How overdue the oldest due check in ping_queue is.", nil, nil)
+	notiQueueDepth = prometheus.NewDesc("upbot_notification_queue_depth",
+		"Jobs waiting in noti_queue.", nil, nil)
+)
+
+// QueueCollector reads the Redis queues at scrape time, so every replica
+// reports the same shared values.
+type QueueCollector struct {
+	redisClient *redis.Client
+}
+
+func NewQueueCollector(redisClient *redis.Client) *QueueCollector {
+	return &QueueCollector{redisClient: redisClient}
+}
+
+func (c *QueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- pingQueueDepth
+	ch <- pingQueueDue
+	ch <- pingQueueLag
+	ch <- notiQueueDepth
+}
+
+func (c *QueueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), scrapeTimeout)
+	defer cancel()
+	now := time.Now()
+
+	if depth, err := c.redisClient.ZCard(ctx, "ping_queue").Result(); err == nil {
+		ch <- prometheus.MustNewConstMetric(pingQueueDepth, prometheus.GaugeValue, float64(depth))
+	}
+	if due, err := c.redisClient.ZCount(ctx, "ping_queue", "-inf", strconv.FormatInt(now.Unix(), 10)).Result(); err == nil {
+		ch <- prometheus.MustNewConstMetric(pingQueueDue, prometheus.GaugeValue, float64(due))
+	}
+	if oldest, err := c.redisClient.ZRangeWithScores(ctx, "ping_queue", 0, 0).Result(); err == nil {
+		lag := 0.0
+		if len(oldest) > 0 {
+			if overdue := float64(now.Unix()) - oldest[0].Score; overdue > 0 {
+				lag = overdue
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(pingQueueLag, prometheus.GaugeValue, lag)
+	}
+	if depth, err := c.redisClient.LLen(ctx, "noti_queue").Result(); err == nil {
+		ch <- prometheus.MustNewConstMetric(notiQueueDepth, prometheus.GaugeValue, float64(depth))
+	}
+}
+
+var (
+	monitorLabels = []string{"task_id", "organization_id", "type", "url"}
+
+	monitorUp = prometheus.NewDesc("upbot_monitor_up",
+		"Whether the monitor is up (1) or has an open incident (0).", monitorLabels, nil)
+	monitorLatency = prometheus.NewDesc("upbot_monitor_latency_seconds",
+		"Duration of the monitor's latest check.", monitorLabels, nil)
+	monitorLastCheck = prometheus.NewDesc("upbot_monitor_last_check_timestamp_seconds",
+		"Time of the monitor's latest check.", monitorLabels, nil)
+	monitorCertExpiry = prometheus.NewDesc("upbot_monitor_cert_expiry_timestamp_seconds",
+		"Expiry of the TLS certificate seen by the latest HTTPS check.", monitorLabels, nil)
+)
+
+// MonitorCollector exports one set of gauges per active monitor across all
+// organizations. It is meant for operators and is served separately from the
+// service metrics.
+type MonitorCollector struct {
+	taskRepo     repository.TaskRepository
+	logRepo      repository.LogRepository
+	incidentRepo repository.IncidentRepository
+}
+
+func NewMonitorCollector(taskRepo repository.TaskRepository, logRepo repository.LogRepository, incidentRepo repository.IncidentRepository) *MonitorCollector {
+	return &MonitorCollector{taskRepo: taskRepo, logRepo: logRepo, incidentRepo: incidentRepo}
+}
+
+func (c *MonitorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- monitorUp
+	ch <- monitorLatency
+	ch <- monitorLastCheck
+	ch <- monitorCertExpiry
+}
+
+func (c *MonitorCollector) Collect(ch chan<- prometheus.Metric) {
+	tasks, err := c.taskRepo.ListActive()
+	if err != nil {
+		slog.Error("Error listing monitors for metrics", "error", err)
+		return
+	}
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	latest, err := c.logRepo.LatestByTaskIDs(ids)
+	if err != nil {
+		slog.Error("Error loading latest checks for metrics", "error", err)
+		return
+	}
+	open, err := c.incidentRepo.OpenTaskIDs(ids)
+	if err != nil {
+		slog.Error("Error loading open incidents for metrics", "error", err)
+		return
+	}
+
+	for _, t := range tasks {
+		labels := []string{strconv.FormatUint(uint64(t.ID), 10), strconv.FormatUint(uint64(t.OrganizationID), 10), t.Type, t.URL}
+		up := 1.0
+		if open[t.ID] {
+			up = 0
+		}
+		ch <- prometheus.MustNewConstMetric(monitorUp, prometheus.GaugeValue, up, labels...)
+		if l, ok := latest[t.ID]; ok {
+			ch <- prometheus.MustNewConstMetric(monitorLatency, prometheus.GaugeValue, float64(l.TimeTake)/1000, labels...)
+			ch <- prometheus.MustNewConstMetric(monitorLastCheck, prometheus.GaugeValue, float64(l.Time.Unix()), labels...)
+		}
+		if t.CertExpiresAt != nil {
+			ch <- prometheus.MustNewConstMetric(monitorCertExpiry, prometheus.GaugeValue, float64(t.CertExpiresAt.Unix()), labels...)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package metrics defines the Prometheus metrics exposed on /metrics and
+// the hooks that feed them.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upbot_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests by route.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	ProbeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "upbot_probe_duration_seconds",
+		Help:    "Duration of monitor checks.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"type", "result"})
+
+	NotificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upbot_notifications_total",
+		Help: "Notification deliveries by channel type and result.",
+	}, []string{"channel", "result"})
+
+	RedisErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upbot_redis_errors_total",
+		Help: "Failed Redis commands by command name.",
+	}, []string{"command"})
+
+	DBErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "upbot_db_errors_total",
+		Help: "Failed database operations by operation.",
+	}, []string{"operation"})
+)
+
+// Result is the label value for a success flag.
+func Result(ok bool) string {
+	if ok {
+		return "success"
+	}
+	return "failure"
+}
+
+// GinMiddleware records the latency of every request under its route
+// pattern, so /api/ping/1 and /api/ping/2 share a series.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequestDuration.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// redisHook counts failed commands. A missing key (redis.Nil) is not a
+// failure.
+type redisHook struct{}
+
+// RedisHook returns a hook for redis.Client.AddHook.
+func RedisHook() redis.Hook {
+	return redisHook{}
+}
+
+func (redisHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (redisHook) AfterProcess(_ context.Context, cmd redis.Cmder) error {
+	countRedisError(cmd)
+	return nil
+}
+
+func (redisHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (redisHook) AfterProcessPipeline(_ context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		countRedisError(cmd)
+	}
+	return nil
+}
+
+func countRedisError(cmd redis.Cmder) {
+	if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+		RedisErrorsTotal.WithLabelValues(cmd.Name()).Inc()
+	}
+}
+
+// InstrumentGORM counts failed database operations. Lookups that find
+// nothing are not failures.
+func InstrumentGORM(db *gorm.DB) error {
+	count := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				DBErrorsTotal.WithLabelValues(operation).Inc()
+			}
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().After("gorm:create").Register("metrics:create", count("create")),
+		cb.Query().After("gorm:query").Register("metrics:query", count("query")),
+		cb.Update().After("gorm:update").Register("metrics:update", count("update")),
+		cb.Delete().After("gorm:delete").Register("metrics:delete", count("delete")),
+		cb.Row().After("gorm:row").Register("metrics:row", count("row")),
+		cb.Raw().After("gorm:raw").Register("metrics:raw", count("raw")),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,18 +11,28 @@ import (
 
 type User struct {
 	gorm.Model
-	Email string `json:"email" gorm:"uniqueIndex;not null"`
-	Tasks []Task `json:"tasks" gorm:"foreignKey:UserID"`
+	Email  string `json:"email" gorm:"uniqueIndex;not null"`
+	PlanID *uint  `json:"planId"`
+	Tasks  []Task `json:"tasks" gorm:"foreignKey:UserID"`
 }
 
+// Task is owned by an organization; UserID records who created it.
+// IntervalSeconds is the time between checks, bounded by the plan.
 type Task struct {
 	gorm.Model
-	URL           string  `json:"url" gorm:"not null"`
-	IsActive      bool    `json:"isActive" gorm:"default:true"`
-	NotifyDiscord bool    `json:"notifyDiscord" gorm:"default:false"`
-	WebHook       *string `json:"webHook" gorm:"default:NULL"`
-	UserID        uint    `json:"userId" gorm:"not null"`
-	FailCount     int     `json:"failCount" gorm:"default:0"`
+	URL             string     `json:"url" gorm:"not null"`
+	IsActive        bool       `json:"isActive" gorm:"default:true"`
+	NotifyDiscord   bool       `json:"notifyDiscord" gorm:"default:false"`
+	WebHook         *string    `json:"webHook" gorm:"default:NULL"`
+	UserID          uint       `json:"userId" gorm:"not null"`
+	OrganizationID  uint       `json:"organizationId" gorm:"index"`
+	FailCount       int        `json:"failCount" gorm:"default:0"`
+	Type            string     `json:"type" gorm:"default:'http';not null"`
+	Tags            StringList `json:"tags" gorm:"type:jsonb"`
+	IntervalSeconds int        `json:"intervalSeconds" gorm:"default:600;not null"`
+	BadgeToken      *string    `json:"-" gorm:"uniqueIndex"`
+	CertExpiresAt   *time.Time `json:"certExpiresAt"`
+	Channels        []Channel  `json:"channels,omitempty" gorm:"many2many:task_channels"`
 	// Logs are omitted from the main struct to avoid fetching them every time
 }
 
@@ -32,3 +45,398 @@ type Log struct {
 	IsSuccess   bool      `json:"isSuccess"`
 	RespCode    int       `json:"respCode"`
 }
+
+// Task check types.
+const (
+	TaskTypeHTTP = "http"
+)
+
+// Incident is opened when a task crosses its failure threshold and resolved
+// on the first successful probe afterwards.
+type Incident struct {
+	gorm.Model
+	TaskID     uint       `json:"taskId" gorm:"index;not null"`
+	StartedAt  time.Time  `json:"startedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+	Cause      string     `json:"cause"`
+	RespCode   int        `json:"respCode"`
+}
+
+// Channel types understood by the notifier package.
+const (
+	ChannelDiscord   = "discord"
+	ChannelEmail     = "email"
+	ChannelPagerDuty = "pagerduty"
+	ChannelOpsgenie  = "opsgenie"
+	ChannelTelegram  = "telegram"
+	ChannelTeams     = "teams"
+)
+
+// Channel is an organization's notification destination that can be
+// attached to any number of its tasks. UserID records who created it.
+type Channel struct {
+	gorm.Model
+	UserID         uint    `json:"userId" gorm:"index;not null"`
+	OrganizationID uint    `json:"organizationId" gorm:"index"`
+	Name           string  `json:"name" gorm:"not null"`
+	Type           string  `json:"type" gorm:"not null"`
+	Config         JSONMap `json:"config" gorm:"type:jsonb"`
+	// Optional text/template sources rendered with notifier.TemplateData.
+	TitleTemplate string `json:"titleTemplate" gorm:"type:text"`
+	BodyTemplate  string `json:"bodyTemplate" gorm:"type:text"`
+	// Delivery settings; zero values fall back to the worker defaults.
+	// PagerDuty and Opsgenie channels get every event immediately and
+	// must leave them unset.
+	RateLimitPerMinute int    `json:"rateLimitPerMinute" gorm:"default:0"`
+	GroupWindowSeconds int    `json:"groupWindowSeconds" gorm:"default:0"`
+	DigestMode         string `json:"digestMode" gorm:"default:''"`
+	Tasks              []Task `json:"-" gorm:"many2many:task_channels"`
+	// TaskIDs lists the IDs of Tasks in responses.
+	TaskIDs []uint `json:"taskIds" gorm:"-"`
+}
+
+// DigestHourly collects a channel's events and delivers them once an hour.
+const DigestHourly = "hourly"
+
+// JSONMap stores flat string settings (API keys, base URLs, chat IDs) in a
+// single jsonb column.
+type JSONMap map[string]string
+
+func (m JSONMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (m *JSONMap) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		*m = JSONMap{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("unsupported JSONMap source type %T", value)
+	}
+	return json.Unmarshal(b, m)
+}
+
+// StringList stores a list of strings, such as task tags, in a jsonb column.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (l *StringList) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("unsupported StringList source type %T", value)
+	}
+	return json.Unmarshal(b, l)
+}
+
+// LatencyBucketBounds are the upper bounds, in milliseconds, of the latency
+// histogram kept in the rollups. The last bucket collects everything slower.
+var LatencyBucketBounds = []int64{50, 100, 200, 300, 500, 750, 1000, 1500, 2000, 3000, 5000, 10000}
+
+// CheckAggregate holds the rolled-up figures shared by hourly and daily
+// stats. Percentiles are exact for the period; the histogram lets longer
+// windows estimate them across periods.
+type CheckAggregate struct {
+	Checks         int64     `json:"checks"`
+	Failures       int64     `json:"failures"`
+	LatencySum     int64     `json:"latencySum"`
+	LatencyMin     int64     `json:"latencyMin"`
+	LatencyMax     int64     `json:"latencyMax"`
+	LatencyP50     float64   `json:"latencyP50"`
+	LatencyP95     float64   `json:"latencyP95"`
+	LatencyP99     float64   `json:"latencyP99"`
+	LatencyBuckets Int64List `json:"latencyBuckets" gorm:"type:jsonb"`
+}
+
+// HourlyStat aggregates one task's checks for one UTC hour.
+type HourlyStat struct {
+	TaskID         uint      `json:"taskId" gorm:"primaryKey;autoIncrement:false"`
+	Hour           time.Time `json:"hour" gorm:"primaryKey"`
+	CheckAggregate `gorm:"embedded"`
+}
+
+// DailyStat aggregates one task's checks for one UTC day.
+type DailyStat struct {
+	TaskID         uint      `json:"taskId" gorm:"primaryKey;autoIncrement:false"`
+	Day            time.Time `json:"day" gorm:"primaryKey"`
+	CheckAggregate `gorm:"embedded"`
+}
+
+// Int64List stores a list of counters in a jsonb column.
+type Int64List []int64
+
+func (l Int64List) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (l *Int64List) Scan(value interface{}) error {
+	var b []byte
+	switch v := value.(type) {
+	case nil:
+		*l = Int64List{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("unsupported Int64List source type %T", value)
+	}
+	return json.Unmarshal(b, l)
+}
+
+// API key scopes. Sessions from a login carry ScopeAll.
+const (
+	ScopeAll           = "*"
+	ScopeRead          = "read"
+	ScopeMonitorsWrite = "monitors:write"
+	ScopeChannelsWrite = "channels:write"
+)
+
+// APIKeyScopes are the scopes a user may grant to an API key.
+var APIKeyScopes = []string{ScopeRead, ScopeMonitorsWrite, ScopeChannelsWrite}
+
+// HasScope reports whether granted allows required. Any write scope also
+// allows reading.
+func HasScope(granted []string, required string) bool {
+	for _, s := range granted {
+		if s == ScopeAll || s == required || required == ScopeRead {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey is a personal token for programmatic access. Only the SHA-256 hash
+// of the key is stored; Prefix lets users recognise it in listings.
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `json:"userId" gorm:"index;not null"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"`
+	Hash       string     `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     StringList `json:"scopes" gorm:"type:jsonb"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+// RefreshToken is the server-side record of an issued refresh token. Tokens
+// rotate on every use; all tokens descended from one login share a FamilyID
+// so that replaying a rotated token revokes the whole chain.
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `json:"userId" gorm:"index;not null"`
+	FamilyID  string     `json:"familyId" gorm:"index;not null"`
+	Hash      string     `json:"-" gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt"`
+}
+
+// Membership roles, from most to least privileged.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+var roleRanks = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3, RoleOwner: 4}
+
+// ValidRole reports whether role is one of the membership roles.
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAtLeast reports whether role grants everything min does.
+func RoleAtLeast(role, min string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[min]
+}
+
+// Organization owns tasks and channels and is shared through memberships.
+// Every user gets a personal organization on first use.
+type Organization struct {
+	gorm.Model
+	Name        string       `json:"name" gorm:"not null"`
+	Personal    bool         `json:"personal" gorm:"default:false"`
+	PlanID      *uint        `json:"planId"`
+	Memberships []Membership `json:"-"`
+}
+
+type Membership struct {
+	gorm.Model
+	OrganizationID uint          `json:"organizationId" gorm:"uniqueIndex:idx_membership_org_user;not null"`
+	UserID         uint          `json:"userId" gorm:"uniqueIndex:idx_membership_org_user;index;not null"`
+	Role           string        `json:"role" gorm:"not null"`
+	User           *User         `json:"user,omitempty"`
+	Organization   *Organization `json:"organization,omitempty"`
+}
+
+// Invitation lets the holder of an emailed token join an organization.
+type Invitation struct {
+	gorm.Model
+	OrganizationID uint       `json:"organizationId" gorm:"index;not null"`
+	Email          string     `json:"email" gorm:"not null"`
+	Role           string     `json:"role" gorm:"not null"`
+	TokenHash      string     `json:"-" gorm:"uniqueIndex;not null"`
+	InvitedByID    uint       `json:"invitedById"`
+	ExpiresAt      time.Time  `json:"expiresAt"`
+	AcceptedAt     *time.Time `json:"acceptedAt"`
+}
+
+// Plan limits what an organization can use. It applies to an organization
+// directly, or through the plan of the organization's owner, falling back
+// to the server's default plan. Zero limits mean unlimited.
+type Plan struct {
+	gorm.Model
+	Name               string `json:"name" gorm:"uniqueIndex;not null"`
+	MaxMonitors        int    `json:"maxMonitors"`
+	MinIntervalSeconds int    `json:"minIntervalSeconds"`
+	// LogRetentionDays shortens raw log retention below the server default.
+	LogRetentionDays int `json:"logRetentionDays"`
+	MaxChannels      int `json:"maxChannels"`
+	// CheckTypes lists the allowed task types; empty allows all.
+	CheckTypes StringList `json:"checkTypes" gorm:"type:jsonb"`
+}
+
+// AllowsType reports whether the plan permits tasks of the given type.
+func (p *Plan) AllowsType(taskType string) bool {
+	if len(p.CheckTypes) == 0 {
+		return true
+	}
+	for _, t := range p.CheckTypes {
+		if t == taskType {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusPage publicly shows the state of selected tasks of an organization
+// at /status/<slug>. Task URLs stay hidden unless ShowURLs is set.
+type StatusPage struct {
+	gorm.Model
+	OrganizationID uint                `json:"organizationId" gorm:"index;not null"`
+	Slug           string              `json:"slug" gorm:"uniqueIndex;not null"`
+	Title          string              `json:"title" gorm:"not null"`
+	Description    string              `json:"description"`
+	LogoURL        string              `json:"logoUrl"`
+	AccentColor    string              `json:"accentColor"`
+	ShowURLs       bool                `json:"showUrls" gorm:"default:false"`
+	Monitors       []StatusPageMonitor `json:"monitors" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// StatusPageMonitor places a task on a status page under a display name and
+// an optional group heading.
+type StatusPageMonitor struct {
+	ID           uint   `json:"id" gorm:"primarykey"`
+	StatusPageID uint   `json:"-" gorm:"index;not null"`
+	TaskID       uint   `json:"taskId" gorm:"not null"`
+	Name         string `json:"name"`
+	GroupName    string `json:"group"`
+	Position     int    `json:"-"`
+}
+
+// Announcement kinds.
+const (
+	AnnouncementIncident    = "incident"
+	AnnouncementMaintenance = "maintenance"
+)
+
+// AnnouncementStatuses lists the statuses of each announcement kind in
+// order; the last one closes the announcement.
+var AnnouncementStatuses = map[string][]string{
+	AnnouncementIncident:    {"investigating", "identified", "monitoring", "resolved"},
+	AnnouncementMaintenance: {"scheduled", "in_progress", "completed"},
+}
+
+// Announcement is an incident or a scheduled maintenance posted on a status
+// page. Status is that of its latest update.
+type Announcement struct {
+	gorm.Model
+	StatusPageID   uint                 `json:"statusPageId" gorm:"index;not null"`
+	Kind           string               `json:"kind" gorm:"not null"`
+	Title          string               `json:"title" gorm:"not null"`
+	Status         string               `json:"status" gorm:"not null"`
+	ScheduledStart *time.Time           `json:"scheduledStart"`
+	ScheduledEnd   *time.Time           `json:"scheduledEnd"`
+	ClosedAt       *time.Time           `json:"closedAt"`
+	AuthorID       uint                 `json:"authorId"`
+	Updates        []AnnouncementUpdate `json:"updates"`
+}
+
+// ValidStatus reports whether status belongs to the announcement's kind.
+func (a *Announcement) ValidStatus(status string) bool {
+	for _, s := range AnnouncementStatuses[a.Kind] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosingStatus reports whether status is the final one of the kind.
+func (a *Announcement) IsClosingStatus(status string) bool {
+	statuses := AnnouncementStatuses[a.Kind]
+	return len(statuses) > 0 && statuses[len(statuses)-1] == status
+}
+
+// AnnouncementUpdate is one post in an announcement's timeline. Each update
+// is sent to the page's subscribers.
+type AnnouncementUpdate struct {
+	ID             uint          `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time     `json:"createdAt"`
+	AnnouncementID uint          `json:"-" gorm:"index;not null"`
+	Status         string        `json:"status" gorm:"not null"`
+	Message        string        `json:"message" gorm:"type:text"`
+	AuthorID       uint          `json:"authorId"`
+	Announcement   *Announcement `json:"-"`
+}
+
+// StatusSubscriber receives a status page's announcements by email or
+// webhook. Email subscriptions count once confirmed; Token confirms and
+// cancels the subscription.
+type StatusSubscriber struct {
+	gorm.Model
+	StatusPageID uint       `json:"statusPageId" gorm:"index;not null"`
+	Email        string     `json:"email,omitempty"`
+	WebhookURL   string     `json:"webhookUrl,omitempty"`
+	Token        string     `json:"-" gorm:"uniqueIndex;not null"`
+	ConfirmedAt  *time.Time `json:"confirmedAt"`
+}
@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"fmt"
+	"strings"
+	"upbot-server-go/internal/models"
+)
+
+// SupportsBatching reports whether a channel type can summarise several
+// events in one message. Incident tools pair each trigger with its resolve
+// through the dedup key, so they always receive individual events.
+func SupportsBatching(channelType string) bool {
+	switch channelType {
+	case models.ChannelPagerDuty, models.ChannelOpsgenie:
+		return false
+	default:
+		return true
+	}
+}
+
+// Summarize folds several events into one whose Title and Message describe
+// all of them. The originals are kept in Batch.
+func Summarize(events []Event) Event {
+	if len(events) == 1 {
+		return events[0]
+	}
+
+	var down, up int
+	var lines []string
+	for _, e := range events {
+		if e.Type == EventUp {
+			up++
+			lines = append(lines, "✅ "+summary(e))
+		} else {
+			down++
+			lines = append(lines, "❌ "+summary(e))
+		}
+	}
+
+	var parts []string
+	if down > 0 {
+		parts = append(parts, fmt.Sprintf("🚨 %d %s down", down, plural(down, "monitor", "monitors")))
+	}
+	if up > 0 {
+		parts = append(parts, fmt.Sprintf("✅ %d %s recovered", up, plural(up, "monitor", "monitors")))
+	}
+
+	summaryEvent := events[0]
+	summaryEvent.Type = EventUp
+	if down > 0 {
+		summaryEvent.Type = EventDown
+	}
+	summaryEvent.Title = strings.Join(parts, ", ")
+	summaryEvent.Message = strings.Join(lines, "\n")
+	summaryEvent.Batch = events
+	return summaryEvent
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
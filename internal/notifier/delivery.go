@@ -0,0 +1,47 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Delivery is the outcome of a synchronous notification, as reported by the
+// "send test notification" endpoints.
+type Delivery struct {
+	Delivered  bool   `json:"delivered"`
+	StatusCode int    `json:"statusCode"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type deliveryKey struct{}
+
+// recordStatus stores the remote status code on the Delivery carried by ctx.
+func recordStatus(ctx context.Context, statusCode int) {
+	if d, ok := ctx.Value(deliveryKey{}).(*Delivery); ok {
+		d.StatusCode = statusCode
+	}
+}
+
+// Deliver sends the event right away and reports the remote status code and
+// error instead of only logging them.
+func Deliver(ctx context.Context, n Notifier, event Event) *Delivery {
+	delivery := &Delivery{}
+	ctx = context.WithValue(ctx, deliveryKey{}, delivery)
+
+	start := time.Now()
+	err := n.Notify(ctx, event)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		delivery.Error = err.Error()
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			delivery.StatusCode = statusErr.StatusCode
+		}
+		return delivery
+	}
+	delivery.Delivered = true
+	return delivery
+}
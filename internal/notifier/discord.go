@@ -0,0 +1,100 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type DiscordEmbed struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Color       int          `json:"color"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+	Footer      EmbedFooter  `json:"footer,omitempty"`
+}
+
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type EmbedFooter struct {
+	Text string `json:"text"`
+	Icon string `json:"icon_url"`
+}
+
+type DiscordWebhookPayload struct {
+	Embeds []DiscordEmbed `json:"embeds"`
+}
+
+type discordNotifier struct {
+	webhookURL string
+}
+
+func NewDiscordNotifier(webhookURL string) Notifier {
+	return &discordNotifier{webhookURL: webhookURL}
+}
+
+func (n *discordNotifier) Notify(ctx context.Context, event Event) error {
+	if n.webhookURL == "" {
+		return fmt.Errorf("no Discord webhook URL provided")
+	}
+
+	embed := DiscordEmbed{
+		Title:       "🚨 Server Ping Failure Alert 🚨",
+		Description: fmt.Sprintf("We have detected multiple ping failures for the server at %s.", event.URL),
+		Color:       16711680,
+		Fields: []EmbedField{
+			{
+				Name:   "Server URL",
+				Value:  fmt.Sprintf("[Visit Server](%s)", event.URL),
+				Inline: false,
+			},
+			{
+				Name:   "Status",
+				Value:  "❌ Failed to respond",
+				Inline: true,
+			},
+		},
+		Footer: EmbedFooter{
+			Text: "Please take immediate action.",
+		},
+	}
+
+	if event.Type == EventUp {
+		embed = DiscordEmbed{
+			Title:       "✅ Server Recovered",
+			Description: fmt.Sprintf("The server at %s is responding again.", event.URL),
+			Color:       3066993,
+			Fields: []EmbedField{
+				{
+					Name:   "Server URL",
+					Value:  fmt.Sprintf("[Visit Server](%s)", event.URL),
+					Inline: false,
+				},
+			},
+		}
+		if event.ResolvedAt != nil {
+			embed.Fields = append(embed.Fields, EmbedField{
+				Name:   "Downtime",
+				Value:  event.ResolvedAt.Sub(event.StartedAt).Round(time.Second).String(),
+				Inline: true,
+			})
+		}
+	}
+
+	if event.Title != "" {
+		embed.Title = event.Title
+	}
+	if event.Message != "" {
+		embed.Description = event.Message
+		embed.Footer = EmbedFooter{}
+	}
+
+	payload := DiscordWebhookPayload{
+		Embeds: []DiscordEmbed{embed},
+	}
+	return postJSON(ctx, n.webhookURL, nil, payload)
+}
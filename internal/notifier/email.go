@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"upbot-server-go/internal/infrastructure"
+)
+
+type emailNotifier struct {
+	client infrastructure.EmailClient
+	to     string
+}
+
+func NewEmailNotifier(client infrastructure.EmailClient, to string) Notifier {
+	return &emailNotifier{client: client, to: to}
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, event Event) error {
+	if n.to == "" {
+		return fmt.Errorf("no email recipient provided")
+	}
+
+	if event.Type == EventAnnouncement && event.Announcement != nil {
+		return n.client.SendEmail([]string{n.to}, announcementSubject(event.Announcement), announcementHTML(event.Announcement))
+	}
+
+	url := html.EscapeString(event.URL)
+	dashboardURL := html.EscapeString(firstNonEmpty(event.DashboardURL, defaultDashboardURL))
+	subject := "⚠️ Server Ping Failure Alert for " + event.URL
+	htmlContent := fmt.Sprintf(failureEmailHTML, url, url, dashboardURL)
+	if event.Type == EventUp {
+		subject = "✅ Server Recovered: " + event.URL
+		htmlContent = fmt.Sprintf(recoveryEmailHTML, url, url)
+	}
+
+	// Messages are plain text, and templated ones carry monitor URLs and
+	// error strings that must not become markup.
+	message := strings.ReplaceAll(html.EscapeString(event.Message), "\n", "<br>")
+
+	return n.client.SendEmail([]string{n.to}, firstNonEmpty(event.Title, subject), firstNonEmpty(message, htmlContent))
+}
+
+const defaultDashboardURL = "https://upbot.vineet.tech/dashboard"
+
+func announcementSubject(a *Announcement) string {
+	return fmt.Sprintf("[%s] %s: %s", a.Page, StatusLabel(a.Status), a.Title)
+}
+
+func announcementHTML(a *Announcement) string {
+	schedule := ""
+	if a.ScheduledStart != nil && a.ScheduledEnd != nil {
+		schedule = fmt.Sprintf(`<p style="font-size: 16px; margin: 5px 0;"><strong>Scheduled:</strong> %s to %s</p>`,
+			a.ScheduledStart.UTC().Format("Jan 2, 15:04 MST"), a.ScheduledEnd.UTC().Format("Jan 2, 15:04 MST"))
+	}
+	message := strings.ReplaceAll(html.EscapeString(a.Message), "\n", "<br>")
+	return fmt.Sprintf(announcementEmailHTML,
+		html.EscapeString(a.Title),
+		html.EscapeString(a.Page),
+		StatusLabel(a.Status),
+		schedule,
+		message,
+		html.EscapeString(a.PageURL),
+		html.EscapeString(a.UnsubscribeURL),
+	)
+}
+
+// StatusLabel turns an announcement status such as "in_progress" into
+// "In progress".
+func StatusLabel(status string) string {
+	label := strings.ReplaceAll(status, "_", " ")
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+const failureEmailHTML = `
+	<div style="font-family: Arial, sans-serif; color: #333;">
+		<table style="width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; border-radius: 10px; box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);">
+			<tr>
+				<td style="text-align: center;">
+					<h2 style="color: #d9534f;">🚨 Server Ping Failure Alert 🚨</h2>
+					<p style="font-size: 18px; color: #555;">We've detected multiple failures for your monitored server.</p>
+				</td>
+			</tr>
+			<tr>
+				<td style="padding: 20px; background-color: #fff; border-radius: 8px;">
+					<h3 style="color: #333; font-size: 20px;">Server Details</h3>
+					<p style="font-size: 16px; margin: 5px 0;"><strong>Server URL:</strong> <a href="%s" style="color: #337ab7;"> %s </a></p>
+					<p style="font-size: 16px; margin: 5px 0;"><strong>Failure Count:</strong> 2 consecutive failures</p>
+				</td>
+			</tr>
+			<tr>
+				<td style="padding: 20px;">
+					<h3 style="color: #d9534f; font-size: 18px; text-align: center;">Immediate Action Recommended</h3>
+					<p style="font-size: 16px; color: #666; text-align: center;">
+						We will keep checking your server and let you know as soon as it recovers.
+					</p>
+					<div style="text-align: center; margin-top: 20px;">
+						<a href="%s" style="background-color: #5cb85c; color: white; padding: 12px 20px; border-radius: 5px; font-size: 16px; text-decoration: none;">
+							Go to Dashboard
+						</a>
+					</div>
+				</td>
+			</tr>
+		</table>
+	</div>
+	`
+
+const recoveryEmailHTML = `
+	<div style="font-family: Arial, sans-serif; color: #333;">
+		<table style="width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; border-radius: 10px; box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);">
+			<tr>
+				<td style="text-align: center;">
+					<h2 style="color: #5cb85c;">✅ Server Recovered</h2>
+					<p style="font-size: 18px; color: #555;">Your monitored server is responding again.</p>
+				</td>
+			</tr>
+			<tr>
+				<td style="padding: 20px; background-color: #fff; border-radius: 8px;">
+					<p style="font-size: 16px; margin: 5px 0;"><strong>Server URL:</strong> <a href="%s" style="color: #337ab7;"> %s </a></p>
+				</td>
+			</tr>
+		</table>
+	</div>
+	`
+
+const announcementEmailHTML = `
+	<div style="font-family: Arial, sans-serif; color: #333;">
+		<table style="width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; border-radius: 10px; box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);">
+			<tr>
+				<td style="text-align: center;">
+					<h2 style="color: #333;">%s</h2>
+					<p style="font-size: 16px; color: #555;">%s &middot; <strong>%s</strong></p>
+				</td>
+			</tr>
+			<tr>
+				<td style="padding: 20px; background-color: #fff; border-radius: 8px;">
+					%s
+					<p style="font-size: 16px; margin: 5px 0;">%s</p>
+				</td>
+			</tr>
+			<tr>
+				<td style="padding: 20px; text-align: center;">
+					<a href="%s" style="background-color: #5cb85c; color: white; padding: 12px 20px; border-radius: 5px; font-size: 16px; text-decoration: none;">
+						View Status Page
+					</a>
+					<p style="font-size: 12px; color: #999; margin-top: 20px;"><a href="%s" style="color: #999;">Unsubscribe</a></p>
+				</td>
+			</tr>
+		</table>
+	</div>
+	`
@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"context"
+	"testing"
+)
+
+type recordingEmailClient struct {
+	subject, html string
+}
+
+func (c *recordingEmailClient) SendEmail(to []string, subject, htmlContent string) error {
+	c.subject, c.html = subject, htmlContent
+	return nil
+}
+
+func TestEmailEscapesMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		want  string
+	}{
+		{
+			name:  "templated",
+			event: Event{Type: EventDown, URL: "https://example.com", Message: "<a href=\"https://evil.example\">login</a>\nagain"},
+			want:  "&lt;a href=&#34;https://evil.example&#34;&gt;login&lt;/a&gt;<br>again",
+		},
+		{
+			name:  "batch",
+			event: Event{Type: EventDown, URL: "https://example.com", Message: "<b>2 down</b>", Batch: []Event{{}, {}}},
+			want:  "&lt;b&gt;2 down&lt;/b&gt;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingEmailClient{}
+			if err := NewEmailNotifier(client, "ops@example.com").Notify(context.Background(), tt.event); err != nil {
+				t.Fatal(err)
+			}
+			if client.html != tt.want {
+				t.Fatalf("html = %q, want %q", client.html, tt.want)
+			}
+		})
+	}
+}
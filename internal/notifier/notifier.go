@@ -0,0 +1,250 @@
+// Package notifier delivers task incident events to external channels.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+	"upbot-server-go/internal/infrastructure"
+	"upbot-server-go/internal/models"
+)
+
+type EventType string
+
+const (
+	// EventDown is sent when a task opens an incident.
+	EventDown EventType = "down"
+	// EventUp is sent when the incident is resolved by a successful probe.
+	EventUp EventType = "up"
+	// EventAnnouncement is a status page update sent to the page's
+	// subscribers.
+	EventAnnouncement EventType = "announcement"
+)
+
+// Event is the channel-agnostic description of a state change that every
+// notifier renders in its own format.
+type Event struct {
+	Type       EventType
+	TaskID     uint
+	IncidentID uint
+	URL        string
+	StatusCode int
+	Error      string
+	Duration   int64 // milliseconds, same unit as models.Log.TimeTake
+	StartedAt  time.Time
+	ResolvedAt *time.Time
+
+	DashboardURL string
+
+	// Title and Message carry text rendered from channel templates. Notifiers
+	// fall back to their built-in wording when they are empty.
+	Title   string
+	Message string
+
+	// Batch holds the original events when this event summarises a group.
+	Batch []Event `json:",omitempty"`
+
+	// Announcement is set on EventAnnouncement events.
+	Announcement *Announcement `json:",omitempty"`
+}
+
+// Announcement is an incident update or maintenance notice posted on a
+// status page.
+type Announcement struct {
+	Page           string     `json:"page"`
+	PageURL        string     `json:"pageUrl"`
+	Kind           string     `json:"kind"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	Message        string     `json:"message"`
+	ScheduledStart *time.Time `json:"scheduledStart,omitempty"`
+	ScheduledEnd   *time.Time `json:"scheduledEnd,omitempty"`
+	PostedAt       time.Time  `json:"postedAt"`
+	// UnsubscribeURL cancels the recipient's subscription.
+	UnsubscribeURL string `json:"unsubscribeUrl"`
+}
+
+// DedupKey identifies the incident in systems that pair triggers with
+// resolves (PagerDuty dedup_key, Opsgenie alias).
+func (e Event) DedupKey() string {
+	return fmt.Sprintf("upbot-task-%d-incident-%d", e.TaskID, e.IncidentID)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// New builds the notifier for a stored channel, applying its templates.
+func New(channel *models.Channel, emailClient infrastructure.EmailClient) (Notifier, error) {
+	n, err := newForType(channel, emailClient)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := Templates{Title: channel.TitleTemplate, Body: channel.BodyTemplate}
+	if templates.IsZero() {
+		return n, nil
+	}
+	if err := templates.Validate(); err != nil {
+		return nil, err
+	}
+	return &templatedNotifier{next: n, templates: templates}, nil
+}
+
+// secretConfigKeys are the channel settings that grant access to the
+// destination: webhook URLs, API keys, tokens and email recipients.
+var secretConfigKeys = map[string]bool{
+	"webhookUrl": true,
+	"to":         true,
+	"routingKey": true,
+	"apiKey":     true,
+	"botToken":   true,
+}
+
+// MaskConfig returns a copy of a channel config with secret values masked
+// down to their last 4 characters, for readers who cannot edit channels.
+func MaskConfig(cfg models.JSONMap) models.JSONMap {
+	masked := make(models.JSONMap, len(cfg))
+	for key, value := range cfg {
+		if secretConfigKeys[key] && value != "" {
+			value = MaskSecret(value)
+		}
+		masked[key] = value
+	}
+	return masked
+}
+
+// UnmaskConfig restores secrets in an updated config that a client sent
+// back in their masked form, so that editing other fields keeps them.
+func UnmaskConfig(updated, stored models.JSONMap) models.JSONMap {
+	for key, value := range updated {
+		if secretConfigKeys[key] && stored[key] != "" && value == MaskSecret(stored[key]) {
+			updated[key] = stored[key]
+		}
+	}
+	return updated
+}
+
+// MaskSecret keeps the last 4 characters of values long enough that they
+// do not reveal most of the secret.
+func MaskSecret(value string) string {
+	if len(value) < 12 {
+		return "****"
+	}
+	return "****" + value[len(value)-4:]
+}
+
+func newForType(channel *models.Channel, emailClient infrastructure.EmailClient) (Notifier, error) {
+	cfg := channel.Config
+	switch channel.Type {
+	case models.ChannelDiscord:
+		if cfg["webhookUrl"] == "" {
+			return nil, fmt.Errorf("discord channel %d has no webhookUrl", channel.ID)
+		}
+		return NewDiscordNotifier(cfg["webhookUrl"]), nil
+	case models.ChannelEmail:
+		if cfg["to"] == "" {
+			return nil, fmt.Errorf("email channel %d has no recipient", channel.ID)
+		}
+		return NewEmailNotifier(emailClient, cfg["to"]), nil
+	case models.ChannelPagerDuty:
+		if cfg["routingKey"] == "" {
+			return nil, fmt.Errorf("pagerduty channel %d has no routingKey", channel.ID)
+		}
+		return NewPagerDutyNotifier(cfg["routingKey"], cfg["baseUrl"]), nil
+	case models.ChannelOpsgenie:
+		if cfg["apiKey"] == "" {
+			return nil, fmt.Errorf("opsgenie channel %d has no apiKey", channel.ID)
+		}
+		return NewOpsgenieNotifier(cfg["apiKey"], cfg["baseUrl"]), nil
+	case models.ChannelTelegram:
+		if cfg["botToken"] == "" || cfg["chatId"] == "" {
+			return nil, fmt.Errorf("telegram channel %d needs botToken and chatId", channel.ID)
+		}
+		return NewTelegramNotifier(cfg["botToken"], cfg["chatId"], cfg["baseUrl"]), nil
+	case models.ChannelTeams:
+		if cfg["webhookUrl"] == "" {
+			return nil, fmt.Errorf("teams channel %d has no webhookUrl", channel.ID)
+		}
+		return NewTeamsNotifier(cfg["webhookUrl"]), nil
+	default:
+		return nil, fmt.Errorf("unknown channel type %q", channel.Type)
+	}
+}
+
+// StatusError is returned when the remote API answers with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("remote returned non-OK status: %s", e.Status)
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func postJSON(ctx context.Context, url string, headers map[string]string, payload interface{}) error {
+	return postJSONWith(ctx, httpClient, url, headers, payload)
+}
+
+func postJSONWith(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", redactURLError(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", redactURLError(err))
+	}
+	defer resp.Body.Close()
+	recordStatus(ctx, resp.StatusCode)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+// redactURLError strips the request URL from transport errors. Webhook
+// URLs and the Telegram bot path carry credentials, and these errors are
+// logged and returned by channel tests.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	redacted := "[redacted]"
+	if u, parseErr := url.Parse(urlErr.URL); parseErr == nil && u.Host != "" {
+		redacted = u.Scheme + "://" + u.Host
+	}
+	return &url.Error{Op: urlErr.Op, URL: redacted, Err: urlErr.Err}
+}
+
+func summary(event Event) string {
+	if event.Title != "" {
+		return event.Title
+	}
+	if event.Type == EventUp {
+		return fmt.Sprintf("%s is back up", event.URL)
+	}
+	if event.Error != "" {
+		return fmt.Sprintf("%s is down: %s", event.URL, event.Error)
+	}
+	return fmt.Sprintf("%s is down (status %d)", event.URL, event.StatusCode)
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"upbot-server-go/internal/models"
+)
+
+func TestMaskConfig(t *testing.T) {
+	stored := models.JSONMap{
+		"webhookUrl": "https://discord.com/api/webhooks/1/abcdefgh",
+		"routingKey": "short",
+		"chatId":     "-100123",
+		"baseUrl":    "https://api.example.com",
+	}
+	masked := MaskConfig(stored)
+	want := map[string]string{
+		"webhookUrl": "****efgh",
+		"routingKey": "****",
+		"chatId":     "-100123",
+		"baseUrl":    "https://api.example.com",
+	}
+	for key, value := range want {
+		if masked[key] != value {
+			t.Errorf("MaskConfig()[%q] = %q, want %q", key, masked[key], value)
+		}
+	}
+	if stored["webhookUrl"] != "https://discord.com/api/webhooks/1/abcdefgh" {
+		t.Fatal("MaskConfig() modified its input")
+	}
+
+	updated := UnmaskConfig(models.JSONMap{
+		"webhookUrl": "****efgh",
+		"routingKey": "new-routing-key",
+		"chatId":     "-100123",
+	}, stored)
+	if updated["webhookUrl"] != stored["webhookUrl"] {
+		t.Errorf("UnmaskConfig() did not restore webhookUrl, got %q", updated["webhookUrl"])
+	}
+	if updated["routingKey"] != "new-routing-key" {
+		t.Errorf("UnmaskConfig() overwrote a changed secret, got %q", updated["routingKey"])
+	}
+}
+
+func TestTransportErrorsOmitSecrets(t *testing.T) {
+	const token = "123456:SECRET-bot-token"
+	// Port 1 on localhost refuses connections.
+	n := NewTelegramNotifier(token, "42", "http://127.0.0.1:1")
+	err := n.Notify(context.Background(), SampleEvent(EventDown, ""))
+	if err == nil {
+		t.Fatal("Notify() succeeded against a closed port")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Fatalf("Notify() error leaks the bot token: %v", err)
+	}
+	if !strings.Contains(err.Error(), "127.0.0.1:1") {
+		t.Fatalf("Notify() error lost the host: %v", err)
+	}
+}
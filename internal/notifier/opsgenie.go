@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const defaultOpsgenieBaseURL = "https://api.opsgenie.com"
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+type opsgenieNotifier struct {
+	apiKey  string
+	baseURL string
+}
+
+// NewOpsgenieNotifier creates and closes alerts through the Opsgenie Alert
+// API, using the incident dedup key as the alert alias.
+func NewOpsgenieNotifier(apiKey, baseURL string) Notifier {
+	if baseURL == "" {
+		baseURL = defaultOpsgenieBaseURL
+	}
+	return &opsgenieNotifier{
+		apiKey:  apiKey,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (n *opsgenieNotifier) Notify(ctx context.Context, event Event) error {
+	headers := map[string]string{"Authorization": "GenieKey " + n.apiKey}
+	alias := event.DedupKey()
+
+	if event.Type == EventUp {
+		closeURL := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", n.baseURL, url.PathEscape(alias))
+		return postJSON(ctx, closeURL, headers, opsgenieClose{
+			Source: "upbot",
+			Note:   summary(event),
+		})
+	}
+
+	alert := opsgenieAlert{
+		Message:     summary(event),
+		Alias:       alias,
+		Description: firstNonEmpty(event.Message, fmt.Sprintf("UpBot detected repeated failures for %s.", event.URL)),
+		Source:      "upbot",
+		Priority:    "P1",
+		Tags:        []string{"upbot"},
+		Details: map[string]string{
+			"url":        event.URL,
+			"taskId":     fmt.Sprint(event.TaskID),
+			"incidentId": fmt.Sprint(event.IncidentID),
+			"statusCode": fmt.Sprint(event.StatusCode),
+			"error":      event.Error,
+		},
+	}
+	return postJSON(ctx, n.baseURL+"/v2/alerts", headers, alert)
+}
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const defaultPagerDutyBaseURL = "https://events.pagerduty.com"
+
+// pagerDutyEvent is the PagerDuty Events API v2 request body.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+type pagerDutyNotifier struct {
+	routingKey string
+	baseURL    string
+}
+
+// NewPagerDutyNotifier triggers and resolves PagerDuty incidents. baseURL can
+// point at a local stand-in; it defaults to the public Events API.
+func NewPagerDutyNotifier(routingKey, baseURL string) Notifier {
+	if baseURL == "" {
+		baseURL = defaultPagerDutyBaseURL
+	}
+	return &pagerDutyNotifier{
+		routingKey: routingKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (n *pagerDutyNotifier) Notify(ctx context.Context, event Event) error {
+	body := pagerDutyEvent{
+		RoutingKey: n.routingKey,
+		DedupKey:   event.DedupKey(),
+	}
+
+	if event.Type == EventUp {
+		body.EventAction = "resolve"
+	} else {
+		body.EventAction = "trigger"
+		body.Payload = &pagerDutyPayload{
+			Summary:   summary(event),
+			Source:    event.URL,
+			Severity:  "critical",
+			Timestamp: event.StartedAt.UTC().Format(time.RFC3339),
+			Component: "upbot",
+			CustomDetails: map[string]interface{}{
+				"taskId":     event.TaskID,
+				"incidentId": event.IncidentID,
+				"statusCode": event.StatusCode,
+				"error":      event.Error,
+				"durationMs": event.Duration,
+			},
+		}
+		if event.Message != "" {
+			body.Payload.CustomDetails["message"] = event.Message
+		}
+		body.Links = []pagerDutyLink{{Href: event.URL, Text: "Monitored URL"}}
+	}
+
+	return postJSON(ctx, n.baseURL+"/v2/enqueue", nil, body)
+}
@@ -0,0 +1,107 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrNonPublicAddress is returned when a public webhook resolves to, or
+// redirects to, an address inside a private or reserved network.
+var ErrNonPublicAddress = errors.New("destination is not a public address")
+
+// reservedPrefixes are special-purpose ranges that netip's predicates do
+// not cover: shared address space, benchmarking and documentation ranges,
+// and IPv6 transition prefixes that can embed private IPv4 addresses.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// IsPublicAddr reports whether addr is a globally routable unicast
+// address. IPv4-mapped IPv6 addresses are judged by their IPv4 address.
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicHTTPClient only connects to public addresses. The check runs on
+// the address actually dialed, after DNS resolution and for every
+// redirect, so a host that re-resolves to an internal address between
+// validation and delivery is still refused. No proxy is used, since the
+// proxy would make the connection instead.
+var publicHTTPClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: dialPublicOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 3 {
+			return errors.New("stopped after 3 redirects")
+		}
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to non-https URL refused")
+		}
+		return nil
+	},
+}
+
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	if network != "tcp4" && network != "tcp6" {
+		return fmt.Errorf("%w: network %s", ErrNonPublicAddress, network)
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// LookupPublic resolves host and fails unless every address is public.
+// It gives subscribers an early error; delivery checks again on connect.
+func LookupPublic(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("host %s does not resolve", host)
+	}
+	for _, addr := range addrs {
+		if !IsPublicAddr(addr) {
+			return ErrNonPublicAddress
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// teamsMessage wraps an Adaptive Card the way Teams incoming webhooks and
+// Workflows expect it.
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	ContentURL  *string      `json:"contentUrl"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string                   `json:"$schema"`
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	Body    []map[string]interface{} `json:"body"`
+	Actions []map[string]interface{} `json:"actions,omitempty"`
+}
+
+type teamsNotifier struct {
+	webhookURL string
+}
+
+// NewTeamsNotifier posts Adaptive Cards to a Teams incoming webhook URL.
+func NewTeamsNotifier(webhookURL string) Notifier {
+	return &teamsNotifier{webhookURL: webhookURL}
+}
+
+func (n *teamsNotifier) Notify(ctx context.Context, event Event) error {
+	if n.webhookURL == "" {
+		return fmt.Errorf("no Teams webhook URL provided")
+	}
+	return postJSON(ctx, n.webhookURL, nil, teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     teamsCard(event),
+		}},
+	})
+}
+
+func teamsCard(event Event) adaptiveCard {
+	title := "🚨 Server Ping Failure Alert 🚨"
+	color := "Attention"
+	text := fmt.Sprintf("We have detected multiple ping failures for the server at %s.", event.URL)
+	facts := []map[string]string{{"title": "Server URL", "value": event.URL}}
+
+	if event.Type == EventUp {
+		title = "✅ Server Recovered"
+		color = "Good"
+		text = fmt.Sprintf("The server at %s is responding again.", event.URL)
+		if event.ResolvedAt != nil {
+			facts = append(facts, map[string]string{
+				"title": "Downtime",
+				"value": event.ResolvedAt.Sub(event.StartedAt).Round(time.Second).String(),
+			})
+		}
+	} else {
+		if event.StatusCode != 0 {
+			facts = append(facts, map[string]string{"title": "Status code", "value": fmt.Sprint(event.StatusCode)})
+		}
+		if event.Error != "" {
+			facts = append(facts, map[string]string{"title": "Error", "value": event.Error})
+		}
+	}
+
+	title = firstNonEmpty(event.Title, title)
+	text = firstNonEmpty(event.Message, text)
+
+	return adaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []map[string]interface{}{
+			{"type": "TextBlock", "text": title, "weight": "Bolder", "size": "Medium", "color": color, "wrap": true},
+			{"type": "TextBlock", "text": text, "wrap": true},
+			{"type": "FactSet", "facts": facts},
+		},
+		Actions: []map[string]interface{}{
+			{"type": "Action.OpenUrl", "title": "Visit Server", "url": event.URL},
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+const defaultTelegramBaseURL = "https://api.telegram.org"
+
+type telegramMessage struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+type telegramNotifier struct {
+	botToken string
+	chatID   string
+	baseURL  string
+}
+
+// NewTelegramNotifier posts messages through the Bot API sendMessage method.
+// baseURL defaults to the public Bot API.
+func NewTelegramNotifier(botToken, chatID, baseURL string) Notifier {
+	if baseURL == "" {
+		baseURL = defaultTelegramBaseURL
+	}
+	return &telegramNotifier{
+		botToken: botToken,
+		chatID:   chatID,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (n *telegramNotifier) Notify(ctx context.Context, event Event) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", n.baseURL, n.botToken)
+	return postJSON(ctx, url, nil, telegramMessage{
+		ChatID:                n.chatID,
+		Text:                  telegramText(event),
+		ParseMode:             "HTML",
+		DisableWebPagePreview: true,
+	})
+}
+
+func telegramText(event Event) string {
+	var b strings.Builder
+	if event.Title != "" || event.Message != "" {
+		if event.Title != "" {
+			fmt.Fprintf(&b, "<b>%s</b>\n", html.EscapeString(event.Title))
+		}
+		b.WriteString(html.EscapeString(event.Message))
+		return strings.TrimSpace(b.String())
+	}
+
+	if event.Type == EventUp {
+		b.WriteString("✅ <b>Server Recovered</b>\n")
+		fmt.Fprintf(&b, "%s is responding again.", html.EscapeString(event.URL))
+		if event.ResolvedAt != nil {
+			fmt.Fprintf(&b, "\nDowntime: %s", event.ResolvedAt.Sub(event.StartedAt).Round(time.Second))
+		}
+		return b.String()
+	}
+
+	b.WriteString("🚨 <b>Server Ping Failure Alert</b> 🚨\n")
+	fmt.Fprintf(&b, "We have detected multiple ping failures for %s.", html.EscapeString(event.URL))
+	if event.StatusCode != 0 {
+		fmt.Fprintf(&b, "\nStatus code: %d", event.StatusCode)
+	}
+	if event.Error != "" {
+		fmt.Fprintf(&b, "\nError: %s", html.EscapeString(event.Error))
+	}
+	return b.String()
+}
@@ -0,0 +1,241 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// maxTemplateOutput caps what a user template may render, so that a
+// template cannot exhaust memory in the API or the notification worker.
+const maxTemplateOutput = 8 << 10
+
+var errTemplateOutputTooLarge = fmt.Errorf("template output exceeds %d bytes", maxTemplateOutput)
+
+// TemplateData is the set of variables available to user-defined channel
+// templates, e.g. "{{.URL}} is down since {{.IncidentStart.Format \"15:04\"}}".
+type TemplateData struct {
+	Event         string     // "down" or "up"
+	IsDown        bool       // true for failure alerts
+	IsUp          bool       // true for recovery notices
+	TaskID        uint       // monitor ID
+	IncidentID    uint       // incident ID shared by the down and up events
+	URL           string     // monitored URL
+	StatusCode    int        // HTTP status of the failing probe, 0 if none
+	Error         string     // error or response summary of the failing probe
+	Duration      int64      // probe duration in milliseconds
+	IncidentStart time.Time  // when the incident was opened
+	ResolvedAt    *time.Time // when the incident was resolved, nil while down
+	Downtime      string     // incident length, empty while down
+	DashboardURL  string     // link to the UpBot dashboard
+}
+
+// TemplateVariables documents TemplateData for API clients.
+var TemplateVariables = map[string]string{
+	"Event":         `"down" or "up"`,
+	"IsDown":        "true for failure alerts",
+	"IsUp":          "true for recovery notices",
+	"TaskID":        "monitor ID",
+	"IncidentID":    "incident ID shared by the down and up events",
+	"URL":           "monitored URL",
+	"StatusCode":    "HTTP status of the failing probe, 0 if none",
+	"Error":         "error or response summary of the failing probe",
+	"Duration":      "probe duration in milliseconds",
+	"IncidentStart": "time the incident was opened",
+	"ResolvedAt":    "time the incident was resolved, empty while down",
+	"Downtime":      "incident length, empty while down",
+	"DashboardURL":  "link to the UpBot dashboard",
+}
+
+func newTemplateData(event Event) TemplateData {
+	data := TemplateData{
+		Event:         string(event.Type),
+		IsDown:        event.Type == EventDown,
+		IsUp:          event.Type == EventUp,
+		TaskID:        event.TaskID,
+		IncidentID:    event.IncidentID,
+		URL:           event.URL,
+		StatusCode:    event.StatusCode,
+		Error:         event.Error,
+		Duration:      event.Duration,
+		IncidentStart: event.StartedAt,
+		ResolvedAt:    event.ResolvedAt,
+		DashboardURL:  event.DashboardURL,
+	}
+	if event.ResolvedAt != nil {
+		data.Downtime = event.ResolvedAt.Sub(event.StartedAt).Round(time.Second).String()
+	}
+	return data
+}
+
+// Templates holds the optional title and body templates of a channel. Empty
+// templates leave the notifier's built-in wording in place.
+type Templates struct {
+	Title string
+	Body  string
+}
+
+func (t Templates) IsZero() bool {
+	return t.Title == "" && t.Body == ""
+}
+
+// Validate parses both templates without executing them.
+func (t Templates) Validate() error {
+	if _, err := parseTemplate("title", t.Title); err != nil {
+		return err
+	}
+	if _, err := parseTemplate("body", t.Body); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Apply renders the templates into event.Title and event.Message.
+func (t Templates) Apply(event Event) (Event, error) {
+	data := newTemplateData(event)
+	var err error
+	if t.Title != "" {
+		if event.Title, err = render("title", t.Title, data); err != nil {
+			return event, err
+		}
+	}
+	if t.Body != "" {
+		if event.Message, err = render("body", t.Body, data); err != nil {
+			return event, err
+		}
+	}
+	return event, nil
+}
+
+func render(name, text string, data TemplateData) (string, error) {
+	tmpl, err := parseTemplate(name, text)
+	if err != nil {
+		return "", err
+	}
+	var buf limitedBuffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// parseTemplate parses a user template. Loops and template calls are
+// rejected: without them, rendering time is bounded by the template's size.
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil {
+			continue
+		}
+		if err := checkTemplateNode(t.Tree.Root); err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", name, err)
+		}
+	}
+	return tmpl, nil
+}
+
+func checkTemplateNode(node parse.Node) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkTemplateNode(child); err != nil {
+				return err
+			}
+		}
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		return errors.New("range is not allowed")
+	case *parse.TemplateNode:
+		return errors.New("template calls are not allowed")
+	}
+	return nil
+}
+
+func checkBranch(n *parse.BranchNode) error {
+	if err := checkTemplateNode(n.List); err != nil {
+		return err
+	}
+	return checkTemplateNode(n.ElseList)
+}
+
+// limitedBuffer fails writes that would grow it past maxTemplateOutput,
+// which aborts template execution.
+type limitedBuffer struct {
+	strings.Builder
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > maxTemplateOutput {
+		return 0, errTemplateOutputTooLarge
+	}
+	return b.Builder.Write(p)
+}
+
+// SampleEvent returns a realistic event for template previews.
+func SampleEvent(eventType EventType, dashboardURL string) Event {
+	started := time.Now().Add(-12 * time.Minute).Truncate(time.Second)
+	event := Event{
+		Type:         eventType,
+		TaskID:       42,
+		IncidentID:   7,
+		URL:          "https://example.com/health",
+		StatusCode:   503,
+		Error:        "Service Unavailable",
+		Duration:     1240,
+		StartedAt:    started,
+		DashboardURL: dashboardURL,
+	}
+	if eventType == EventUp {
+		resolved := started.Add(12 * time.Minute)
+		event.ResolvedAt = &resolved
+	}
+	return event
+}
+
+type templatedNotifier struct {
+	next      Notifier
+	templates Templates
+}
+
+func (n *templatedNotifier) Notify(ctx context.Context, event Event) error {
+	if len(event.Batch) > 0 {
+		// Render each grouped event so the summary lines use the channel's wording.
+		batch := make([]Event, len(event.Batch))
+		for i, e := range event.Batch {
+			rendered, err := n.templates.Apply(e)
+			if err != nil {
+				return err
+			}
+			batch[i] = rendered
+		}
+		return n.next.Notify(ctx, Summarize(batch))
+	}
+
+	event, err := n.templates.Apply(event)
+	if err != nil {
+		return err
+	}
+	return n.next.Notify(ctx, event)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
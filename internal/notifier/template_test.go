@@ -0,0 +1,79 @@
+package notifier
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTemplatesValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates Templates
+		wantErr   string
+	}{
+		{"empty", Templates{}, ""},
+		{"fields", Templates{Title: "{{.URL}} is {{.Event}}", Body: "{{if .IsDown}}{{.Error}}{{else}}back after {{.Downtime}}{{end}}"}, ""},
+		{"with", Templates{Body: "{{with .ResolvedAt}}{{.Format \"15:04\"}}{{end}}"}, ""},
+		{"syntax error", Templates{Title: "{{.URL"}, "invalid title template"},
+		{"range", Templates{Body: "{{range 1000000}}x{{end}}"}, "range is not allowed"},
+		{"range in if", Templates{Body: "{{if .IsDown}}{{range 10}}x{{end}}{{end}}"}, "range is not allowed"},
+		{"range in else", Templates{Title: "{{with .ResolvedAt}}{{else}}{{range 10}}x{{end}}{{end}}"}, "range is not allowed"},
+		{"template call", Templates{Body: `{{define "a"}}x{{end}}{{template "a"}}`}, "template calls are not allowed"},
+		{"recursive define", Templates{Body: `{{define "a"}}{{template "a"}}{{end}}`}, "template calls are not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.templates.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderOutputLimit(t *testing.T) {
+	data := newTemplateData(SampleEvent(EventDown, "https://example.com"))
+	tests := []struct {
+		name    string
+		text    string
+		wantLen int
+		wantErr bool
+	}{
+		{"small", "{{.URL}}", len("https://example.com/health"), false},
+		{"at limit", strings.Repeat("x", maxTemplateOutput), maxTemplateOutput, false},
+		{"literal over limit", strings.Repeat("x", maxTemplateOutput+1), 0, true},
+		{"printf over limit", `{{printf "%0999999d" 1}}`, 0, true},
+		{"many actions over limit", strings.Repeat(`{{printf "%01000d" 1}}`, 10), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := render("body", tt.text, data)
+			if tt.wantErr {
+				if !errors.Is(err, errTemplateOutputTooLarge) {
+					t.Fatalf("render() error = %v, want %v", err, errTemplateOutputTooLarge)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("render() = %v", err)
+			}
+			if len(out) != tt.wantLen {
+				t.Fatalf("len(render()) = %d, want %d", len(out), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestRenderRejectsRange(t *testing.T) {
+	data := newTemplateData(SampleEvent(EventDown, ""))
+	if _, err := render("body", "{{range 1000000}}{{range 1000000}}x{{end}}{{end}}", data); err == nil {
+		t.Fatal("render() accepted a nested range")
+	}
+}
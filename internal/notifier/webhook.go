@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// webhookPayload is the JSON body posted to generic webhooks.
+type webhookPayload struct {
+	Type         EventType     `json:"type"`
+	TaskID       uint          `json:"taskId,omitempty"`
+	URL          string        `json:"url,omitempty"`
+	Title        string        `json:"title,omitempty"`
+	Message      string        `json:"message,omitempty"`
+	StartedAt    *time.Time    `json:"startedAt,omitempty"`
+	ResolvedAt   *time.Time    `json:"resolvedAt,omitempty"`
+	Announcement *Announcement `json:"announcement,omitempty"`
+}
+
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier posts events as JSON to an arbitrary URL.
+func NewWebhookNotifier(url string) Notifier {
+	return &webhookNotifier{url: url, client: httpClient}
+}
+
+// NewPublicWebhookNotifier is NewWebhookNotifier for URLs supplied by
+// anonymous users: it refuses to connect to non-public addresses.
+func NewPublicWebhookNotifier(url string) Notifier {
+	return &webhookNotifier{url: url, client: publicHTTPClient}
+}
+
+func (n *webhookNotifier) Notify(ctx context.Context, event Event) error {
+	if n.url == "" {
+		return fmt.Errorf("no webhook URL provided")
+	}
+
+	payload := webhookPayload{
+		Type:         event.Type,
+		TaskID:       event.TaskID,
+		URL:          event.URL,
+		Title:        event.Title,
+		Message:      event.Message,
+		ResolvedAt:   event.ResolvedAt,
+		Announcement: event.Announcement,
+	}
+	if !event.StartedAt.IsZero() {
+		payload.StartedAt = &event.StartedAt
+	}
+	if event.Announcement != nil {
+		payload.Title = announcementSubject(event.Announcement)
+		payload.Message = event.Announcement.Message
+	}
+	return postJSONWith(ctx, n.client, n.url, nil, payload)
+}
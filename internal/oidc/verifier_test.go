@@ -0,0 +1,114 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "client-1"
+
+func serveJWKS(t *testing.T, kid string, key *rsa.PublicKey) *httptest.Server {
+	t.Helper()
+	jwks := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := serveJWKS(t, "k1", &key.PublicKey)
+	verifier := NewVerifier(NewKeySet(server.URL), GoogleIssuers, testClientID)
+
+	now := time.Now()
+	validClaims := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":            "https://accounts.google.com",
+			"aud":            testClientID,
+			"sub":            "123",
+			"email":          "a@example.com",
+			"email_verified": "true",
+			"iat":            now.Unix(),
+			"exp":            now.Add(time.Hour).Unix(),
+		}
+	}
+	sign := func(method jwt.SigningMethod, kid string, signKey interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		raw, err := token.SignedString(signKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", sign(jwt.SigningMethodRS256, "k1", key, validClaims()), true},
+		{"wrong signing key", sign(jwt.SigningMethodRS256, "k1", otherKey, validClaims()), false},
+		{"unknown key ID", sign(jwt.SigningMethodRS256, "k2", key, validClaims()), false},
+		{"HMAC with the public key", sign(jwt.SigningMethodHS256, "k1", []byte("secret"), validClaims()), false},
+		{"none algorithm", sign(jwt.SigningMethodNone, "k1", jwt.UnsafeAllowNoneSignatureType, validClaims()), false},
+		{"wrong audience", sign(jwt.SigningMethodRS256, "k1", key, with("aud", "client-2")), false},
+		{"wrong issuer", sign(jwt.SigningMethodRS256, "k1", key, with("iss", "https://evil.example.com")), false},
+		{"expired", sign(jwt.SigningMethodRS256, "k1", key, with("exp", now.Add(-time.Minute).Unix())), false},
+		{"no expiry", sign(jwt.SigningMethodRS256, "k1", key, with("exp", nil)), false},
+		{"issued in the future", sign(jwt.SigningMethodRS256, "k1", key, with("iat", now.Add(time.Hour).Unix())), false},
+		{"garbage", "not.a.token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), tt.token)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Fatalf("Verify() error = %v, want %v", err, ErrInvalidIDToken)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() = %v", err)
+			}
+			if claims.Email != "a@example.com" || !bool(claims.EmailVerified) {
+				t.Fatalf("unexpected claims %+v", claims)
+			}
+		})
+	}
+}
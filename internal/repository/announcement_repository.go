@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AnnouncementRepository stores status page announcements and their
+// subscribers.
+type AnnouncementRepository interface {
+	WithContext(ctx context.Context) AnnouncementRepository
+	Create(announcement *models.Announcement) error
+	FindByID(id uint) (*models.Announcement, error)
+	ListByPageID(pageID uint, since time.Time, limit int) ([]models.Announcement, error)
+	AddUpdate(announcement *models.Announcement, update *models.AnnouncementUpdate) error
+	FindUpdate(id uint) (*models.AnnouncementUpdate, error)
+	Delete(announcement *models.Announcement) error
+
+	CreateSubscriber(subscriber *models.StatusSubscriber) error
+	FindSubscriberByToken(token string) (*models.StatusSubscriber, error)
+	FindSubscriber(pageID uint, email, webhookURL string) (*models.StatusSubscriber, error)
+	FindSubscriberByID(id uint) (*models.StatusSubscriber, error)
+	ConfirmSubscriber(subscriber *models.StatusSubscriber, at time.Time) error
+	DeleteSubscriber(subscriber *models.StatusSubscriber) error
+	ListSubscribers(pageID uint) ([]models.StatusSubscriber, error)
+	ListConfirmedSubscribers(pageID uint) ([]models.StatusSubscriber, error)
+}
+
+type announcementRepository struct {
+	db *gorm.DB
+}
+
+func NewAnnouncementRepository(db *gorm.DB) AnnouncementRepository {
+	return &announcementRepository{db: db}
+}
+
+func (r *announcementRepository) WithContext(ctx context.Context) AnnouncementRepository {
+	return &announcementRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create stores the announcement together with its first update.
+func (r *announcementRepository) Create(announcement *models.Announcement) error {
+	return r.db.Create(announcement).Error
+}
+
+func (r *announcementRepository) FindByID(id uint) (*models.Announcement, error) {
+	var announcement models.Announcement
+	err := r.preloadUpdates().First(&announcement, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+// ListByPageID returns the announcements of a page that are still open or
+// were updated after since, most recently updated first.
+func (r *announcementRepository) ListByPageID(pageID uint, since time.Time, limit int) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := r.preloadUpdates().
+		Where("status_page_id = ? AND (closed_at IS NULL OR updated_at >= ?)", pageID, since).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&announcements).Error
+	return announcements, err
+}
+
+// AddUpdate appends an update and saves the announcement's new status in
+// one transaction.
+func (r *announcementRepository) AddUpdate(announcement *models.Announcement, update *models.AnnouncementUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		update.AnnouncementID = announcement.ID
+		if err := tx.Create(update).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Updates").Save(announcement).Error
+	})
+}
+
+func (r *announcementRepository) FindUpdate(id uint) (*models.AnnouncementUpdate, error) {
+	var update models.AnnouncementUpdate
+	err := r.db.Preload("Announcement").First(&update, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+func (r *announcementRepository) Delete(announcement *models.Announcement) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("announcement_id = ?", announcement.ID).Delete(&models.AnnouncementUpdate{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(announcement).Error
+	})
+}
+
+func (r *announcementRepository) preloadUpdates() *gorm.DB {
+	return r.db.Preload("Updates", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at DESC, id DESC")
+	})
+}
+
+func (r *announcementRepository) CreateSubscriber(subscriber *models.StatusSubscriber) error {
+	return r.db.Create(subscriber).Error
+}
+
+func (r *announcementRepository) FindSubscriberByToken(token string) (*models.StatusSubscriber, error) {
+	var subscriber models.StatusSubscriber
+	err := r.db.Where("token = ?", token).First(&subscriber).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscriber, nil
+}
+
+// FindSubscriber looks up a page's subscription by email or webhook URL;
+// exactly one of them is set.
+func (r *announcementRepository) FindSubscriber(pageID uint, email, webhookURL string) (*models.StatusSubscriber, error) {
+	var subscriber models.StatusSubscriber
+	err := r.db.Where("status_page_id = ? AND email = ? AND webhook_url = ?", pageID, email, webhookURL).
+		First(&subscriber).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscriber, nil
+}
+
+func (r *announcementRepository) FindSubscriberByID(id uint) (*models.StatusSubscriber, error) {
+	var subscriber models.StatusSubscriber
+	err := r.db.First(&subscriber, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscriber, nil
+}
+
+func (r *announcementRepository) ConfirmSubscriber(subscriber *models.StatusSubscriber, at time.Time) error {
+	subscriber.ConfirmedAt = &at
+	return r.db.Model(subscriber).Update("confirmed_at", at).Error
+}
+
+// DeleteSubscriber removes the subscription for good so the address can
+// subscribe again later.
+func (r *announcementRepository) DeleteSubscriber(subscriber *models.StatusSubscriber) error {
+	return r.db.Unscoped().Delete(subscriber).Error
+}
+
+func (r *announcementRepository) ListSubscribers(pageID uint) ([]models.StatusSubscriber, error) {
+	var subscribers []models.StatusSubscriber
+	err := r.db.Where("status_page_id = ?", pageID).Order("id ASC").Find(&subscribers).Error
+	return subscribers, err
+}
+
+func (r *announcementRepository) ListConfirmedSubscribers(pageID uint) ([]models.StatusSubscriber, error) {
+	var subscribers []models.StatusSubscriber
+	err := r.db.Where("status_page_id = ? AND confirmed_at IS NOT NULL", pageID).Order("id ASC").Find(&subscribers).Error
+	return subscribers, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByID(id uint) (*models.APIKey, error)
+	FindByHash(hash string) (*models.APIKey, error)
+	ListByUserID(userID uint) ([]models.APIKey, error)
+	Revoke(key *models.APIKey, at time.Time) error
+	TouchLastUsed(id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByID(id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.First(&key, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByHash(hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.Where("hash = ?", hash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUserID(userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("id ASC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Revoke(key *models.APIKey, at time.Time) error {
+	key.RevokedAt = &at
+	return r.db.Model(key).Update("revoked_at", at).Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(id uint, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type ChannelRepository interface {
+	WithContext(ctx context.Context) ChannelRepository
+	Create(channel *models.Channel) error
+	Update(channel *models.Channel) error
+	Delete(channel *models.Channel) error
+	FindByID(id uint) (*models.Channel, error)
+	ListByOrgID(orgID uint) ([]models.Channel, error)
+	ListByTaskID(taskID uint) ([]models.Channel, error)
+	ReplaceTasks(channel *models.Channel, tasks []models.Task) error
+}
+
+type channelRepository struct {
+	db *gorm.DB
+}
+
+func NewChannelRepository(db *gorm.DB) ChannelRepository {
+	return &channelRepository{db: db}
+}
+
+func (r *channelRepository) WithContext(ctx context.Context) ChannelRepository {
+	return &channelRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *channelRepository) Create(channel *models.Channel) error {
+	return r.db.Omit("Tasks.*").Create(channel).Error
+}
+
+func (r *channelRepository) Update(channel *models.Channel) error {
+	return r.db.Omit("Tasks").Save(channel).Error
+}
+
+func (r *channelRepository) Delete(channel *models.Channel) error {
+	if err := r.db.Model(channel).Association("Tasks").Clear(); err != nil {
+		return err
+	}
+	return r.db.Delete(channel).Error
+}
+
+func (r *channelRepository) FindByID(id uint) (*models.Channel, error) {
+	var channel models.Channel
+	err := r.db.Preload("Tasks").First(&channel, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (r *channelRepository) ListByOrgID(orgID uint) ([]models.Channel, error) {
+	var channels []models.Channel
+	err := r.db.Preload("Tasks").Where("organization_id = ?", orgID).Order("id ASC").Find(&channels).Error
+	return channels, err
+}
+
+func (r *channelRepository) ListByTaskID(taskID uint) ([]models.Channel, error) {
+	var channels []models.Channel
+	err := r.db.Joins("JOIN task_channels ON task_channels.channel_id = channels.id").
+		Where("task_channels.task_id = ?", taskID).
+		Find(&channels).Error
+	return channels, err
+}
+
+func (r *channelRepository) ReplaceTasks(channel *models.Channel, tasks []models.Task) error {
+	return r.db.Model(channel).Association("Tasks").Replace(tasks)
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type IncidentRepository interface {
+	WithContext(ctx context.Context) IncidentRepository
+	Create(incident *models.Incident) error
+	FindByID(id uint) (*models.Incident, error)
+	FindOpenByTaskID(taskID uint) (*models.Incident, error)
+	Resolve(incident *models.Incident, at time.Time) error
+	OpenTaskIDs(taskIDs []uint) (map[uint]bool, error)
+	ListByTaskID(taskID uint, since time.Time) ([]models.Incident, error)
+	ListByTaskIDs(taskIDs []uint, since time.Time) ([]models.Incident, error)
+}
+
+type incidentRepository struct {
+	db *gorm.DB
+}
+
+func NewIncidentRepository(db *gorm.DB) IncidentRepository {
+	return &incidentRepository{db: db}
+}
+
+func (r *incidentRepository) WithContext(ctx context.Context) IncidentRepository {
+	return &incidentRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *incidentRepository) Create(incident *models.Incident) error {
+	return r.db.Create(incident).Error
+}
+
+func (r *incidentRepository) FindByID(id uint) (*models.Incident, error) {
+	var incident models.Incident
+	err := r.db.First(&incident, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// FindOpenByTaskID returns the unresolved incident for a task, or
+// gorm.ErrRecordNotFound if the task is currently healthy.
+func (r *incidentRepository) FindOpenByTaskID(taskID uint) (*models.Incident, error) {
+	var incident models.Incident
+	err := r.db.Where("task_id = ? AND resolved_at IS NULL", taskID).
+		Order("started_at DESC").
+		First(&incident).Error
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+func (r *incidentRepository) Resolve(incident *models.Incident, at time.Time) error {
+	incident.ResolvedAt = &at
+	return r.db.Model(incident).Update("resolved_at", at).Error
+}
+
+// OpenTaskIDs reports which of the given tasks have an unresolved incident.
+func (r *incidentRepository) OpenTaskIDs(taskIDs []uint) (map[uint]bool, error) {
+	open := make(map[uint]bool)
+	if len(taskIDs) == 0 {
+		return open, nil
+	}
+
+	var ids []uint
+	err := r.db.Model(&models.Incident{}).
+		Where("task_id IN ? AND resolved_at IS NULL", taskIDs).
+		Distinct().
+		Pluck("task_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		open[id] = true
+	}
+	return open, nil
+}
+
+// ListByTaskID returns the incidents of a task that started after since,
+// oldest first.
+func (r *incidentRepository) ListByTaskID(taskID uint, since time.Time) ([]models.Incident, error) {
+	var incidents []models.Incident
+	err := r.db.Where("task_id = ? AND started_at >= ?", taskID, since).
+		Order("started_at ASC").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// ListByTaskIDs returns the incidents of the given tasks that started after
+// since, newest first.
+func (r *incidentRepository) ListByTaskIDs(taskIDs []uint, since time.Time) ([]models.Incident, error) {
+	var incidents []models.Incident
+	if len(taskIDs) == 0 {
+		return incidents, nil
+	}
+	err := r.db.Where("task_id IN ? AND started_at >= ?", taskIDs, since).
+		Order("started_at DESC").
+		Find(&incidents).Error
+	return incidents, err
+}
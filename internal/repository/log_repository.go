@@ -1,14 +1,35 @@
 package repository
 
 import (
+	"context"
+	"time"
 	"upbot-server-go/internal/models"
 
 	"gorm.io/gorm"
 )
 
 type LogRepository interface {
+	WithContext(ctx context.Context) LogRepository
 	Create(log *models.Log) error
-	TrimLogs(taskID uint, maxLogs int) error
+	DeleteBefore(cutoff time.Time) (int64, error)
+	LatestByTaskIDs(taskIDs []uint) (map[uint]models.Log, error)
+	UptimeByTaskIDs(taskIDs []uint, since time.Time) (map[uint]float64, error)
+	ListByTaskID(taskID uint, opts LogListOptions) ([]models.Log, error)
+}
+
+// LogListOptions selects a page of a task's check history, newest first.
+type LogListOptions struct {
+	From    *time.Time
+	To      *time.Time
+	Success *bool
+	Before  *LogCursor
+	Limit   int
+}
+
+// LogCursor is the position of the last log of the previous page.
+type LogCursor struct {
+	Time time.Time `json:"t"`
+	ID   uint      `json:"id"`
 }
 
 type logRepository struct {
@@ -19,22 +40,86 @@ func NewLogRepository(db *gorm.DB) LogRepository {
 	return &logRepository{db: db}
 }
 
+func (r *logRepository) WithContext(ctx context.Context) LogRepository {
+	return &logRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *logRepository) Create(log *models.Log) error {
 	return r.db.Create(log).Error
 }
 
-func (r *logRepository) TrimLogs(taskID uint, maxLogs int) error {
-	var logCount int64
-	r.db.Model(&models.Log{}).Where("task_id = ?", taskID).Count(&logCount)
-	if logCount >= int64(maxLogs) {
-		// Delete the oldest logs, keeping only maxLogs-1 so we can add one more
-		// Or just delete the oldest one. The original code deleted 1.
-		// Let's be robust: delete any logs that are outside the latest (maxLogs - 1)
-		// But for simplicity and matching original logic:
-		return r.db.Where("task_id = ?", taskID).
-			Order("time ASC").
-			Limit(1).
-			Delete(&models.Log{}).Error
-	}
-	return nil
+// DeleteBefore permanently removes logs older than cutoff. Their figures
+// survive in the hourly and daily rollups.
+func (r *logRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("time < ?", cutoff).Delete(&models.Log{})
+	return result.RowsAffected, result.Error
+}
+
+// LatestByTaskIDs returns the most recent log of each task.
+func (r *logRepository) LatestByTaskIDs(taskIDs []uint) (map[uint]models.Log, error) {
+	latest := make(map[uint]models.Log, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return latest, nil
+	}
+
+	var logs []models.Log
+	err := r.db.Raw(`SELECT DISTINCT ON (task_id) * FROM logs
+		WHERE task_id IN ? AND deleted_at IS NULL
+		ORDER BY task_id, time DESC`, taskIDs).Scan(&logs).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		latest[l.TaskID] = l
+	}
+	return latest, nil
+}
+
+// UptimeByTaskIDs returns the percentage of successful checks since the
+// given time. Tasks without checks in the window are omitted.
+func (r *logRepository) UptimeByTaskIDs(taskIDs []uint, since time.Time) (map[uint]float64, error) {
+	uptime := make(map[uint]float64, len(taskIDs))
+	if len(taskIDs) == 0 {
+		return uptime, nil
+	}
+
+	var rows []struct {
+		TaskID    uint
+		Total     int64
+		Successes int64
+	}
+	err := r.db.Model(&models.Log{}).
+		Select("task_id, COUNT(*) AS total, COUNT(*) FILTER (WHERE is_success) AS successes").
+		Where("task_id IN ? AND time >= ?", taskIDs, since).
+		Group("task_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.Total > 0 {
+			uptime[row.TaskID] = float64(row.Successes) * 100 / float64(row.Total)
+		}
+	}
+	return uptime, nil
+}
+
+func (r *logRepository) ListByTaskID(taskID uint, opts LogListOptions) ([]models.Log, error) {
+	q := r.db.Where("task_id = ?", taskID)
+	if opts.From != nil {
+		q = q.Where("time >= ?", *opts.From)
+	}
+	if opts.To != nil {
+		q = q.Where("time < ?", *opts.To)
+	}
+	if opts.Success != nil {
+		q = q.Where("is_success = ?", *opts.Success)
+	}
+	if opts.Before != nil {
+		q = q.Where("(time, id) < (?, ?)", opts.Before.Time, opts.Before.ID)
+	}
+
+	var logs []models.Log
+	err := q.Order("time DESC, id DESC").Limit(opts.Limit).Find(&logs).Error
+	return logs, err
 }
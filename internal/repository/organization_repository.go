@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"time"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// OrganizationRepository stores organizations, their memberships and
+// pending invitations.
+type OrganizationRepository interface {
+	Create(org *models.Organization, ownerID uint) error
+	FindByID(id uint) (*models.Organization, error)
+	FindPersonal(userID uint) (*models.Organization, error)
+	AssignUnowned(userID, orgID uint) error
+	UsersWithUnowned() ([]uint, error)
+
+	FindMembership(orgID, userID uint) (*models.Membership, error)
+	ListMembershipsByUserID(userID uint) ([]models.Membership, error)
+	ListMembers(orgID uint) ([]models.Membership, error)
+	CountOwners(orgID uint) (int64, error)
+	UpdateRole(membership *models.Membership, role string) error
+	DeleteMembership(membership *models.Membership) error
+
+	CreateInvitation(invitation *models.Invitation) error
+	FindInvitation(id uint) (*models.Invitation, error)
+	FindInvitationByHash(hash string) (*models.Invitation, error)
+	ListPendingInvitations(orgID uint) ([]models.Invitation, error)
+	DeleteInvitation(invitation *models.Invitation) error
+	AcceptInvitation(invitation *models.Invitation, userID uint, at time.Time) error
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// Create saves the organization with ownerID as its first owner.
+func (r *organizationRepository) Create(org *models.Organization, ownerID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(org).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Membership{
+			OrganizationID: org.ID,
+			UserID:         ownerID,
+			Role:           models.RoleOwner,
+		}).Error
+	})
+}
+
+func (r *organizationRepository) FindByID(id uint) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.First(&org, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) FindPersonal(userID uint) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.Joins("JOIN memberships ON memberships.organization_id = organizations.id AND memberships.deleted_at IS NULL").
+		Where("organizations.personal = ? AND memberships.user_id = ? AND memberships.role = ?", true, userID, models.RoleOwner).
+		Order("organizations.id ASC").
+		First(&org).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// AssignUnowned moves tasks and channels created before organizations
+// existed into the user's organization.
+func (r *organizationRepository) AssignUnowned(userID, orgID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Task{}).
+			Where("user_id = ? AND (organization_id IS NULL OR organization_id = 0)", userID).
+			Update("organization_id", orgID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Channel{}).
+			Where("user_id = ? AND (organization_id IS NULL OR organization_id = 0)", userID).
+			Update("organization_id", orgID).Error
+	})
+}
+
+// UsersWithUnowned lists users that still have tasks or channels without an
+// organization.
+func (r *organizationRepository) UsersWithUnowned() ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		SELECT user_id FROM tasks WHERE (organization_id IS NULL OR organization_id = 0) AND deleted_at IS NULL
+		UNION
+		SELECT user_id FROM channels WHERE (organization_id IS NULL OR organization_id = 0) AND deleted_at IS NULL`).
+		Scan(&ids).Error
+	return ids, err
+}
+
+func (r *organizationRepository) FindMembership(orgID, userID uint) (*models.Membership, error) {
+	var membership models.Membership
+	err := r.db.Where("organization_id = ? AND user_id = ?", orgID, userID).First(&membership).Error
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *organizationRepository) ListMembershipsByUserID(userID uint) ([]models.Membership, error) {
+	var memberships []models.Membership
+	err := r.db.Preload("Organization").Where("user_id = ?", userID).Order("organization_id ASC").Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *organizationRepository) ListMembers(orgID uint) ([]models.Membership, error) {
+	var memberships []models.Membership
+	err := r.db.Preload("User").Where("organization_id = ?", orgID).Order("id ASC").Find(&memberships).Error
+	return memberships, err
+}
+
+func (r *organizationRepository) CountOwners(orgID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Membership{}).Where("organization_id = ? AND role = ?", orgID, models.RoleOwner).Count(&count).Error
+	return count, err
+}
+
+func (r *organizationRepository) UpdateRole(membership *models.Membership, role string) error {
+	membership.Role = role
+	return r.db.Model(membership).Update("role", role).Error
+}
+
+// DeleteMembership hard deletes so the user can be invited again.
+func (r *organizationRepository) DeleteMembership(membership *models.Membership) error {
+	return r.db.Unscoped().Delete(membership).Error
+}
+
+func (r *organizationRepository) CreateInvitation(invitation *models.Invitation) error {
+	return r.db.Create(invitation).Error
+}
+
+func (r *organizationRepository) FindInvitation(id uint) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.First(&invitation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *organizationRepository) FindInvitationByHash(hash string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := r.db.Where("token_hash = ?", hash).First(&invitation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *organizationRepository) ListPendingInvitations(orgID uint) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	err := r.db.Where("organization_id = ? AND accepted_at IS NULL AND expires_at > ?", orgID, time.Now()).
+		Order("id ASC").Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *organizationRepository) DeleteInvitation(invitation *models.Invitation) error {
+	return r.db.Delete(invitation).Error
+}
+
+// AcceptInvitation marks the invitation used and adds the membership in one
+// transaction. The accepted_at guard makes a second accept fail.
+func (r *organizationRepository) AcceptInvitation(invitation *models.Invitation, userID uint, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Invitation{}).
+			Where("id = ? AND accepted_at IS NULL", invitation.ID).
+			Update("accepted_at", at)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+		invitation.AcceptedAt = &at
+		return tx.Create(&models.Membership{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         userID,
+			Role:           invitation.Role,
+		}).Error
+	})
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"time"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// effectivePlanID resolves the plan of organization o: its own plan, else
+// the plan of its first owner, else the default plan bound to the
+// placeholder.
+const effectivePlanID = `COALESCE(o.plan_id, (
+	SELECT u.plan_id FROM memberships m JOIN users u ON u.id = m.user_id
+	WHERE m.organization_id = o.id AND m.role = 'owner' AND m.deleted_at IS NULL
+	ORDER BY m.id LIMIT 1), ?)`
+
+// minPlanLogRetentionDays keeps raw logs until the daily rollup covering
+// them has been written, whatever the plan says.
+const minPlanLogRetentionDays = 2
+
+type PlanRepository interface {
+	FindByName(name string) (*models.Plan, error)
+	FirstOrCreate(plan *models.Plan) error
+	FindForOrganization(orgID, defaultPlanID uint) (*models.Plan, error)
+	CountChannels(orgID uint) (int64, error)
+	DeleteExpiredLogs(defaultPlanID uint) (int64, error)
+}
+
+type planRepository struct {
+	db *gorm.DB
+}
+
+func NewPlanRepository(db *gorm.DB) PlanRepository {
+	return &planRepository{db: db}
+}
+
+func (r *planRepository) FindByName(name string) (*models.Plan, error) {
+	var plan models.Plan
+	err := r.db.Where("name = ?", name).First(&plan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// FirstOrCreate stores the plan unless one with the same name exists, so
+// limits edited in the database are kept.
+func (r *planRepository) FirstOrCreate(plan *models.Plan) error {
+	return r.db.Where("name = ?", plan.Name).FirstOrCreate(plan).Error
+}
+
+// FindForOrganization returns the plan that applies to the organization, or
+// gorm.ErrRecordNotFound when none does.
+func (r *planRepository) FindForOrganization(orgID, defaultPlanID uint) (*models.Plan, error) {
+	var plans []models.Plan
+	err := r.db.Raw(`SELECT plans.* FROM organizations o
+		JOIN plans ON plans.id = `+effectivePlanID+` AND plans.deleted_at IS NULL
+		WHERE o.id = ?`, defaultPlanID, orgID).Scan(&plans).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &plans[0], nil
+}
+
+func (r *planRepository) CountChannels(orgID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Channel{}).Where("organization_id = ?", orgID).Count(&count).Error
+	return count, err
+}
+
+// DeleteExpiredLogs permanently removes logs older than the log retention
+// of the plan that applies to each task's organization. Logs of plans
+// without their own retention are left to LogRepository.DeleteBefore.
+func (r *planRepository) DeleteExpiredLogs(defaultPlanID uint) (int64, error) {
+	result := r.db.Exec(`DELETE FROM logs USING tasks t, organizations o, plans p
+		WHERE logs.task_id = t.id AND t.organization_id = o.id
+		AND p.id = `+effectivePlanID+` AND p.log_retention_days > 0
+		AND logs.time < ?::timestamptz - make_interval(days => GREATEST(p.log_retention_days, ?))`,
+		defaultPlanID, time.Now(), minPlanLogRetentionDays)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StatRepository maintains the hourly and daily rollups of the logs table.
+type StatRepository interface {
+	RollupHourly(from, to time.Time) error
+	RollupDaily(from, to time.Time) error
+	DeleteHourlyBefore(cutoff time.Time) (int64, error)
+	ListHourly(taskID uint, since time.Time) ([]models.HourlyStat, error)
+	ListDaily(taskID uint, since time.Time) ([]models.DailyStat, error)
+	ListDailyByTaskIDs(taskIDs []uint, since time.Time) ([]models.DailyStat, error)
+}
+
+type statRepository struct {
+	db *gorm.DB
+}
+
+func NewStatRepository(db *gorm.DB) StatRepository {
+	return &statRepository{db: db}
+}
+
+// RollupHourly recomputes the hourly rows for every hour touching [from, to)
+// from the raw logs. It is idempotent, so the current hour can be rolled up
+// repeatedly while it fills.
+func (r *statRepository) RollupHourly(from, to time.Time) error {
+	from = from.UTC().Truncate(time.Hour)
+	return r.db.Exec(rollupSQL("hourly_stats", "hour", "hour"), from, to).Error
+}
+
+// RollupDaily does the same as RollupHourly for whole UTC days.
+func (r *statRepository) RollupDaily(from, to time.Time) error {
+	from = from.UTC().Truncate(24 * time.Hour)
+	return r.db.Exec(rollupSQL("daily_stats", "day", "day"), from, to).Error
+}
+
+func rollupSQL(table, column, unit string) string {
+	buckets := make([]string, 0, len(models.LatencyBucketBounds)+1)
+	lower := "0"
+	for i, bound := range models.LatencyBucketBounds {
+		cond := fmt.Sprintf("time_take <= %d", bound)
+		if i > 0 {
+			cond = fmt.Sprintf("time_take > %s AND %s", lower, cond)
+		}
+		buckets = append(buckets, fmt.Sprintf("COUNT(*) FILTER (WHERE %s)", cond))
+		lower = fmt.Sprint(bound)
+	}
+	buckets = append(buckets, fmt.Sprintf("COUNT(*) FILTER (WHERE time_take > %s)", lower))
+
+	return fmt.Sprintf(`INSERT INTO %[1]s (task_id, %[2]s, checks, failures, latency_sum, latency_min, latency_max,
+		latency_p50, latency_p95, latency_p99, latency_buckets)
+	SELECT task_id,
+		date_trunc('%[3]s', time AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS period,
+		COUNT(*),
+		COUNT(*) FILTER (WHERE NOT is_success),
+		SUM(time_take),
+		MIN(time_take),
+		MAX(time_take),
+		percentile_cont(0.50) WITHIN GROUP (ORDER BY time_take),
+		percentile_cont(0.95) WITHIN GROUP (ORDER BY time_take),
+		percentile_cont(0.99) WITHIN GROUP (ORDER BY time_take),
+		jsonb_build_array(%[4]s)
+	FROM logs
+	WHERE deleted_at IS NULL AND time >= ? AND time < ?
+	GROUP BY task_id, period
+	ON CONFLICT (task_id, %[2]s) DO UPDATE SET
+		checks = EXCLUDED.checks,
+		failures = EXCLUDED.failures,
+		latency_sum = EXCLUDED.latency_sum,
+		latency_min = EXCLUDED.latency_min,
+		latency_max = EXCLUDED.latency_max,
+		latency_p50 = EXCLUDED.latency_p50,
+		latency_p95 = EXCLUDED.latency_p95,
+		latency_p99 = EXCLUDED.latency_p99,
+		latency_buckets = EXCLUDED.latency_buckets`, table, column, unit, strings.Join(buckets, ", "))
+}
+
+func (r *statRepository) DeleteHourlyBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("hour < ?", cutoff).Delete(&models.HourlyStat{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *statRepository) ListHourly(taskID uint, since time.Time) ([]models.HourlyStat, error) {
+	var stats []models.HourlyStat
+	err := r.db.Where("task_id = ? AND hour >= ?", taskID, since.UTC().Truncate(time.Hour)).
+		Order("hour ASC").
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *statRepository) ListDaily(taskID uint, since time.Time) ([]models.DailyStat, error) {
+	var stats []models.DailyStat
+	err := r.db.Where("task_id = ? AND day >= ?", taskID, since.UTC().Truncate(24*time.Hour)).
+		Order("day ASC").
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *statRepository) ListDailyByTaskIDs(taskIDs []uint, since time.Time) ([]models.DailyStat, error) {
+	var stats []models.DailyStat
+	if len(taskIDs) == 0 {
+		return stats, nil
+	}
+	err := r.db.Where("task_id IN ? AND day >= ?", taskIDs, since.UTC().Truncate(24*time.Hour)).
+		Order("day ASC").
+		Find(&stats).Error
+	return stats, err
+}
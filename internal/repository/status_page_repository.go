@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"upbot-server-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type StatusPageRepository interface {
+	WithContext(ctx context.Context) StatusPageRepository
+	Create(page *models.StatusPage) error
+	Update(page *models.StatusPage) error
+	Delete(page *models.StatusPage) error
+	FindByID(id uint) (*models.StatusPage, error)
+	FindBySlug(slug string) (*models.StatusPage, error)
+	ListByOrgID(orgID uint) ([]models.StatusPage, error)
+}
+
+type statusPageRepository struct {
+	db *gorm.DB
+}
+
+func NewStatusPageRepository(db *gorm.DB) StatusPageRepository {
+	return &statusPageRepository{db: db}
+}
+
+func (r *statusPageRepository) WithContext(ctx context.Context) StatusPageRepository {
+	return &statusPageRepository{db: r.db.WithContext(ctx)}
+}
+
+func (r *statusPageRepository) Create(page *models.StatusPage) error {
+	return r.db.Create(page).Error
+}
+
+// Update saves the page and replaces its monitors.
+func (r *statusPageRepository) Update(page *models.StatusPage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Monitors").Save(page).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("status_page_id = ?", page.ID).Delete(&models.StatusPageMonitor{}).Error; err != nil {
+			return err
+		}
+		for i := range page.Monitors {
+			page.Monitors[i].ID = 0
+			page.Monitors[i].StatusPageID = page.ID
+		}
+		if len(page.Monitors) == 0 {
+			return nil
+		}
+		return tx.Create(&page.Monitors).Error
+	})
+}
+
+// Delete removes the page with its monitors, announcements and
+// subscribers. The page is deleted for good so that its slug can be reused.
+func (r *statusPageRepository) Delete(page *models.StatusPage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("status_page_id = ?", page.ID).Delete(&models.StatusPageMonitor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("announcement_id IN (?)",
+			tx.Unscoped().Model(&models.Announcement{}).Select("id").Where("status_page_id = ?", page.ID),
+		).Delete(&models.AnnouncementUpdate{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("status_page_id = ?", page.ID).Delete(&models.Announcement{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("status_page_id = ?", page.ID).Delete(&models.StatusSubscriber{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(page).Error
+	})
+}
+
+func (r *statusPageRepository) FindByID(id uint) (*models.StatusPage, error) {
+	var page models.StatusPage
+	err := r.preloadMonitors().First(&page, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (r *statusPageRepository) FindBySlug(slug string) (*models.StatusPage, error) {
+	var page models.StatusPage
+	err := r.preloadMonitors().Where("slug = ?", slug).First(&page).Error
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (r *statusPageRepository) ListByOrgID(orgID uint) ([]models.StatusPage, error) {
+	var pages []models.StatusPage
+	err := r.preloadMonitors().Where("organization_id = ?", orgID).Order("id ASC").Find(&pages).Error
+	return pages, err
+}
+
+func (r *statusPageRepository) preloadMonitors() *gorm.DB {
+	return r.db.Preload("Monitors", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position ASC")
+	})
+}
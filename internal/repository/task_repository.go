@@ -1,6 +1,11 @@
 package repository
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 	"upbot-server-go/internal/models"
 
 	"gorm.io/gorm"
@@ -9,12 +14,52 @@ import (
 // TaskRepository defines the interface for task-related database operations.
 // This allows us to mock the repository in tests.
 type TaskRepository interface {
+	// WithContext returns a copy whose queries run with ctx, so that they
+	// are traced as children of its span.
+	WithContext(ctx context.Context) TaskRepository
 	Create(task *models.Task) error
-	CountActiveTasksByUserID(userID uint) (int64, error)
-	FindByURLAndUserID(url string, userID uint) (*models.Task, error)
+	CountActiveTasksByOrgID(orgID uint) (int64, error)
+	CountActiveTasksBefore(orgID, taskID uint) (int64, error)
+	FindByURLAndOrgID(url string, orgID uint) (*models.Task, error)
 	GetUserByEmail(email string) (*models.User, error)
 	FindByID(id uint) (*models.Task, error)
+	FindByBadgeToken(token string) (*models.Task, error)
 	CreateUser(user *models.User) error
+	GetUserByID(id uint) (*models.User, error)
+	FindByIDsAndOrgID(ids []uint, orgID uint) ([]models.Task, error)
+	UpdateFailCount(id uint, failCount int) error
+	UpdateBadgeToken(id uint, token string) error
+	UpdateCertExpiry(id uint, expiresAt time.Time) error
+	ListActive() ([]models.Task, error)
+	ListByOrgID(orgID uint) ([]models.Task, error)
+	ListPage(orgID uint, opts TaskListOptions) ([]models.Task, error)
+	Update(task *models.Task) error
+	Delete(task *models.Task) error
+}
+
+// TaskListOptions filters, sorts and pages ListPage. Status is one of "up",
+// "down" (open incident) or "paused"; SortBy is "id", "url" or "createdAt".
+type TaskListOptions struct {
+	Status string
+	Tag    string
+	Type   string
+	Query  string
+	SortBy string
+	Desc   bool
+	After  *TaskCursor
+	Limit  int
+}
+
+// TaskCursor is the sort key of the last row of the previous page.
+type TaskCursor struct {
+	ID    uint   `json:"id"`
+	Value string `json:"v,omitempty"`
+}
+
+var taskSortColumns = map[string]string{
+	"id":        "id",
+	"url":       "url",
+	"createdAt": "created_at",
 }
 
 type taskRepository struct {
@@ -26,6 +71,10 @@ func NewTaskRepository(db *gorm.DB) TaskRepository {
 	return &taskRepository{db: db}
 }
 
+func (r *taskRepository) WithContext(ctx context.Context) TaskRepository {
+	return &taskRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *taskRepository) Create(task *models.Task) error {
 	return r.db.Create(task).Error
 }
@@ -34,9 +83,17 @@ func (r *taskRepository) CreateUser(user *models.User) error {
 	return r.db.Create(user).Error
 }
 
-func (r *taskRepository) CountActiveTasksByUserID(userID uint) (int64, error) {
+func (r *taskRepository) CountActiveTasksByOrgID(orgID uint) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.Task{}).Where("user_id = ? AND is_active = ?", userID, true).Count(&count).Error
+	err := r.db.Model(&models.Task{}).Where("organization_id = ? AND is_active = ?", orgID, true).Count(&count).Error
+	return count, err
+}
+
+// CountActiveTasksBefore counts the organization's active tasks created
+// before taskID.
+func (r *taskRepository) CountActiveTasksBefore(orgID, taskID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Task{}).Where("organization_id = ? AND is_active = ? AND id < ?", orgID, true, taskID).Count(&count).Error
 	return count, err
 }
 
@@ -49,9 +106,18 @@ func (r *taskRepository) FindByID(id uint) (*models.Task, error) {
 	return &task, nil
 }
 
-func (r *taskRepository) FindByURLAndUserID(url string, userID uint) (*models.Task, error) {
+func (r *taskRepository) FindByBadgeToken(token string) (*models.Task, error) {
+	var task models.Task
+	err := r.db.Where("badge_token = ?", token).First(&task).Error
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepository) FindByURLAndOrgID(url string, orgID uint) (*models.Task, error) {
 	var task models.Task
-	err := r.db.Where("url = ? AND user_id = ?", url, userID).First(&task).Error
+	err := r.db.Where("url = ? AND organization_id = ?", url, orgID).First(&task).Error
 	if err != nil {
 		return nil, err
 	}
@@ -66,3 +132,126 @@ func (r *taskRepository) GetUserByEmail(email string) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *taskRepository) GetUserByID(id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *taskRepository) FindByIDsAndOrgID(ids []uint, orgID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	if len(ids) == 0 {
+		return tasks, nil
+	}
+	err := r.db.Where("id IN ? AND organization_id = ?", ids, orgID).Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *taskRepository) UpdateFailCount(id uint, failCount int) error {
+	return r.db.Model(&models.Task{}).Where("id = ?", id).Update("fail_count", failCount).Error
+}
+
+func (r *taskRepository) UpdateBadgeToken(id uint, token string) error {
+	return r.db.Model(&models.Task{}).Where("id = ?", id).Update("badge_token", token).Error
+}
+
+func (r *taskRepository) UpdateCertExpiry(id uint, expiresAt time.Time) error {
+	return r.db.Model(&models.Task{}).Where("id = ?", id).Update("cert_expires_at", expiresAt).Error
+}
+
+// ListActive returns the active tasks of every organization.
+func (r *taskRepository) ListActive() ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.Where("is_active = ?", true).Order("id").Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *taskRepository) ListByOrgID(orgID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.Where("organization_id = ?", orgID).Order("id ASC").Find(&tasks).Error
+	return tasks, err
+}
+
+func (r *taskRepository) Update(task *models.Task) error {
+	return r.db.Omit("Channels").Save(task).Error
+}
+
+// Delete removes a task together with its logs, incidents and channel links.
+func (r *taskRepository) Delete(task *models.Task) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.Log{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("task_id = ?", task.ID).Delete(&models.Incident{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(task).Association("Channels").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(task).Error
+	})
+}
+
+const openIncidentExists = "EXISTS (SELECT 1 FROM incidents WHERE incidents.task_id = tasks.id AND incidents.resolved_at IS NULL AND incidents.deleted_at IS NULL)"
+
+func (r *taskRepository) ListPage(orgID uint, opts TaskListOptions) ([]models.Task, error) {
+	q := r.db.Model(&models.Task{}).Where("organization_id = ?", orgID)
+
+	switch opts.Status {
+	case "":
+	case "paused":
+		q = q.Where("is_active = ?", false)
+	case "down":
+		q = q.Where("is_active = ? AND "+openIncidentExists, true)
+	case "up":
+		q = q.Where("is_active = ? AND NOT "+openIncidentExists, true)
+	default:
+		return nil, fmt.Errorf("unknown status filter %q", opts.Status)
+	}
+	if opts.Tag != "" {
+		tag, _ := json.Marshal([]string{opts.Tag})
+		q = q.Where("tags @> ?::jsonb", string(tag))
+	}
+	if opts.Type != "" {
+		q = q.Where("type = ?", opts.Type)
+	}
+	if opts.Query != "" {
+		q = q.Where("url ILIKE ?", "%"+escapeLike(opts.Query)+"%")
+	}
+
+	column, ok := taskSortColumns[opts.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", opts.SortBy)
+	}
+	dir, cmp := "ASC", ">"
+	if opts.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	if opts.After != nil {
+		switch column {
+		case "id":
+			q = q.Where("id "+cmp+" ?", opts.After.ID)
+		case "created_at":
+			after, err := time.Parse(time.RFC3339Nano, opts.After.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cursor: %w", err)
+			}
+			q = q.Where("(created_at, id) "+cmp+" (?, ?)", after, opts.After.ID)
+		default:
+			q = q.Where("("+column+", id) "+cmp+" (?, ?)", opts.After.Value, opts.After.ID)
+		}
+	}
+
+	var tasks []models.Task
+	err := q.Order(column + " " + dir + ", id " + dir).Limit(opts.Limit).Find(&tasks).Error
+	return tasks, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
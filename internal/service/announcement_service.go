@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"upbot-server-go/internal/infrastructure"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/repository"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrAnnouncementNotFound = errors.New("announcement not found")
+	ErrSubscriberNotFound   = errors.New("subscription not found")
+	ErrSubscribeThrottle    = errors.New("too many subscription requests, try again later")
+)
+
+const (
+	// subscribeLimit caps the confirmation emails sent to one address per
+	// subscribeWindow.
+	subscribeLimit  = 3
+	subscribeWindow = time.Hour
+
+	// announcementDays is how long closed announcements stay on the page
+	// and in the feed.
+	announcementDays = 30
+	maxAnnouncements = 20
+)
+
+// AnnouncementService lets organizations post incident updates and
+// maintenance notices on their status pages, and visitors subscribe to
+// them. Every update is queued on noti_queue for the notification worker to
+// deliver to confirmed subscribers.
+type AnnouncementService interface {
+	CreateAnnouncement(orgID, pageID, userID uint, req AnnouncementRequest) (*models.Announcement, error)
+	ListAnnouncements(orgID, pageID uint) ([]models.Announcement, error)
+	PostUpdate(orgID, pageID, announcementID, userID uint, req AnnouncementUpdateRequest) (*models.Announcement, error)
+	DeleteAnnouncement(orgID, pageID, announcementID uint) error
+	ListSubscribers(orgID, pageID uint) ([]models.StatusSubscriber, error)
+	RemoveSubscriber(orgID, pageID, subscriberID uint) error
+	Subscribe(ctx context.Context, slug string, req SubscribeRequest) (*models.StatusSubscriber, error)
+	ConfirmSubscription(slug, token string) error
+	Unsubscribe(slug, token string) error
+	Feed(slug string) (*AtomFeed, error)
+}
+
+type announcementService struct {
+	repo        repository.AnnouncementRepository
+	pageRepo    repository.StatusPageRepository
+	redisClient *redis.Client
+	emailClient infrastructure.EmailClient
+	publicURL   string
+}
+
+// NewAnnouncementService creates a new instance of AnnouncementService.
+// publicURL is used for the links in emails and the Atom feed.
+func NewAnnouncementService(repo repository.AnnouncementRepository, pageRepo repository.StatusPageRepository, redisClient *redis.Client, emailClient infrastructure.EmailClient, publicURL string) AnnouncementService {
+	return &announcementService{
+		repo:        repo,
+		pageRepo:    pageRepo,
+		redisClient: redisClient,
+		emailClient: emailClient,
+		publicURL:   publicURL,
+	}
+}
+
+// AnnouncementRequest opens an announcement. Status defaults to the first
+// status of the kind; maintenance needs its schedule.
+type AnnouncementRequest struct {
+	Kind           string
+	Title          string
+	Status         string
+	Message        string
+	ScheduledStart *time.Time
+	ScheduledEnd   *time.Time
+}
+
+type AnnouncementUpdateRequest struct {
+	Status  string
+	Message string
+}
+
+// SubscribeRequest carries either an email address or an https webhook URL.
+type SubscribeRequest struct {
+	Email      string
+	WebhookURL string
+}
+
+// announcementJob is the noti_queue payload for an update; it matches
+// worker.NotificationJob.
+type announcementJob struct {
+	AnnouncementUpdateID uint `json:"announcementUpdateId"`
+}
+
+func (s *announcementService) CreateAnnouncement(orgID, pageID, userID uint, req AnnouncementRequest) (*models.Announcement, error) {
+	if _, err := s.findPage(orgID, pageID); err != nil {
+		return nil, err
+	}
+
+	announcement := &models.Announcement{
+		StatusPageID: pageID,
+		Kind:         req.Kind,
+		Title:        strings.TrimSpace(req.Title),
+		AuthorID:     userID,
+	}
+	statuses, ok := models.AnnouncementStatuses[req.Kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown announcement kind %q", req.Kind)
+	}
+	if announcement.Title == "" {
+		return nil, errors.New("title is required")
+	}
+	if req.Kind == models.AnnouncementMaintenance {
+		if req.ScheduledStart == nil || req.ScheduledEnd == nil || !req.ScheduledStart.Before(*req.ScheduledEnd) {
+			return nil, errors.New("maintenance needs a scheduledStart before its scheduledEnd")
+		}
+		announcement.ScheduledStart = req.ScheduledStart
+		announcement.ScheduledEnd = req.ScheduledEnd
+	}
+	status := req.Status
+	if status == "" {
+		status = statuses[0]
+	}
+	if !announcement.ValidStatus(status) {
+		return nil, fmt.Errorf("%s status must be one of %s", req.Kind, strings.Join(statuses, ", "))
+	}
+
+	update := models.AnnouncementUpdate{Status: status, Message: strings.TrimSpace(req.Message), AuthorID: userID}
+	announcement.Status = status
+	if announcement.IsClosingStatus(status) {
+		now := time.Now()
+		announcement.ClosedAt = &now
+	}
+	announcement.Updates = []models.AnnouncementUpdate{update}
+	if err := s.repo.Create(announcement); err != nil {
+		return nil, err
+	}
+	if err := s.notify(announcement.Updates[0].ID); err != nil {
+		return nil, err
+	}
+	return announcement, nil
+}
+
+func (s *announcementService) ListAnnouncements(orgID, pageID uint) ([]models.Announcement, error) {
+	if _, err := s.findPage(orgID, pageID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByPageID(pageID, time.Now().AddDate(0, 0, -announcementDays), maxAnnouncements)
+}
+
+// PostUpdate adds an update to the announcement's timeline and moves it to
+// the update's status. Closed announcements can be reopened.
+func (s *announcementService) PostUpdate(orgID, pageID, announcementID, userID uint, req AnnouncementUpdateRequest) (*models.Announcement, error) {
+	announcement, err := s.findOwned(orgID, pageID, announcementID)
+	if err != nil {
+		return nil, err
+	}
+	status := req.Status
+	if status == "" {
+		status = announcement.Status
+	}
+	if !announcement.ValidStatus(status) {
+		return nil, fmt.Errorf("%s status must be one of %s", announcement.Kind, strings.Join(models.AnnouncementStatuses[announcement.Kind], ", "))
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		return nil, errors.New("message is required")
+	}
+
+	announcement.Status = status
+	announcement.ClosedAt = nil
+	if announcement.IsClosingStatus(status) {
+		now := time.Now()
+		announcement.ClosedAt = &now
+	}
+	update := &models.AnnouncementUpdate{Status: status, Message: message, AuthorID: userID}
+	if err := s.repo.AddUpdate(announcement, update); err != nil {
+		return nil, err
+	}
+	announcement.Updates = append([]models.AnnouncementUpdate{*update}, announcement.Updates...)
+	if err := s.notify(update.ID); err != nil {
+		return nil, err
+	}
+	return announcement, nil
+}
+
+func (s *announcementService) DeleteAnnouncement(orgID, pageID, announcementID uint) error {
+	announcement, err := s.findOwned(orgID, pageID, announcementID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(announcement)
+}
+
+func (s *announcementService) ListSubscribers(orgID, pageID uint) ([]models.StatusSubscriber, error) {
+	if _, err := s.findPage(orgID, pageID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSubscribers(pageID)
+}
+
+func (s *announcementService) RemoveSubscriber(orgID, pageID, subscriberID uint) error {
+	if _, err := s.findPage(orgID, pageID); err != nil {
+		return err
+	}
+	subscriber, err := s.repo.FindSubscriberByID(subscriberID)
+	if err != nil || subscriber.StatusPageID != pageID {
+		return ErrSubscriberNotFound
+	}
+	return s.repo.DeleteSubscriber(subscriber)
+}
+
+// Subscribe registers a webhook right away and emails a confirmation link to
+// an email address, which only receives updates once confirmed. Subscribing
+// an address twice resends the confirmation.
+func (s *announcementService) Subscribe(ctx context.Context, slug string, req SubscribeRequest) (*models.StatusSubscriber, error) {
+	page, err := s.pageRepo.FindBySlug(strings.ToLower(slug))
+	if err != nil {
+		return nil, ErrStatusPageNotFound
+	}
+
+	var email, webhookURL string
+	switch {
+	case req.Email != "" && req.WebhookURL != "":
+		return nil, errors.New("subscribe with either an email or a webhookUrl")
+	case req.Email != "":
+		addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+		if err != nil {
+			return nil, fmt.Errorf("invalid email address: %w", err)
+		}
+		email = strings.ToLower(addr.Address)
+	case req.WebhookURL != "":
+		if err := validateSubscriberWebhook(ctx, req.WebhookURL); err != nil {
+			return nil, err
+		}
+		webhookURL = req.WebhookURL
+	default:
+		return nil, errors.New("email or webhookUrl is required")
+	}
+
+	subscriber, err := s.repo.FindSubscriber(page.ID, email, webhookURL)
+	if err != nil {
+		token, err := randomToken(24)
+		if err != nil {
+			return nil, err
+		}
+		subscriber = &models.StatusSubscriber{
+			StatusPageID: page.ID,
+			Email:        email,
+			WebhookURL:   webhookURL,
+			Token:        token,
+		}
+		if webhookURL != "" {
+			now := time.Now()
+			subscriber.ConfirmedAt = &now
+		}
+		if err := s.repo.CreateSubscriber(subscriber); err != nil {
+			return nil, err
+		}
+	}
+	if email == "" || subscriber.ConfirmedAt != nil {
+		return subscriber, nil
+	}
+
+	rateKey := "status_subscribe_rate:" + email
+	count, err := s.redisClient.Incr(ctx, rateKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check subscription rate: %w", err)
+	}
+	if count == 1 {
+		s.redisClient.Expire(ctx, rateKey, subscribeWindow)
+	}
+	if count > subscribeLimit {
+		return nil, ErrSubscribeThrottle
+	}
+
+	link := s.pageURL(page) + "/confirm?token=" + url.QueryEscape(subscriber.Token)
+	body := fmt.Sprintf(subscribeEmailHTML, html.EscapeString(page.Title), html.EscapeString(link))
+	if err := s.emailClient.SendEmail([]string{email}, "Confirm your subscription to "+page.Title, body); err != nil {
+		return nil, err
+	}
+	return subscriber, nil
+}
+
+func (s *announcementService) ConfirmSubscription(slug, token string) error {
+	subscriber, err := s.findSubscription(slug, token)
+	if err != nil {
+		return err
+	}
+	if subscriber.ConfirmedAt != nil {
+		return nil
+	}
+	return s.repo.ConfirmSubscriber(subscriber, time.Now())
+}
+
+func (s *announcementService) Unsubscribe(slug, token string) error {
+	subscriber, err := s.findSubscription(slug, token)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscriber(subscriber)
+}
+
+func (s *announcementService) findSubscription(slug, token string) (*models.StatusSubscriber, error) {
+	page, err := s.pageRepo.FindBySlug(strings.ToLower(slug))
+	if err != nil {
+		return nil, ErrStatusPageNotFound
+	}
+	if token == "" {
+		return nil, ErrSubscriberNotFound
+	}
+	subscriber, err := s.repo.FindSubscriberByToken(token)
+	if err != nil || subscriber.StatusPageID != page.ID {
+		return nil, ErrSubscriberNotFound
+	}
+	return subscriber, nil
+}
+
+func (s *announcementService) findPage(orgID, pageID uint) (*models.StatusPage, error) {
+	page, err := s.pageRepo.FindByID(pageID)
+	if err != nil || page.OrganizationID != orgID {
+		return nil, ErrStatusPageNotFound
+	}
+	return page, nil
+}
+
+func (s *announcementService) findOwned(orgID, pageID, announcementID uint) (*models.Announcement, error) {
+	if _, err := s.findPage(orgID, pageID); err != nil {
+		return nil, err
+	}
+	announcement, err := s.repo.FindByID(announcementID)
+	if err != nil || announcement.StatusPageID != pageID {
+		return nil, ErrAnnouncementNotFound
+	}
+	return announcement, nil
+}
+
+func (s *announcementService) notify(updateID uint) error {
+	payload, err := json.Marshal(announcementJob{AnnouncementUpdateID: updateID})
+	if err != nil {
+		return err
+	}
+	if err := s.redisClient.LPush(context.Background(), "noti_queue", payload).Err(); err != nil {
+		return fmt.Errorf("failed to notify subscribers: %w", err)
+	}
+	return nil
+}
+
+func (s *announcementService) pageURL(page *models.StatusPage) string {
+	return s.publicURL + "/status/" + page.Slug
+}
+
+// validateSubscriberWebhook only accepts https URLs of public hosts, since
+// anyone can subscribe and the server posts to them. Delivery checks the
+// address again when connecting, as DNS may change in between.
+func validateSubscriberWebhook(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("webhookUrl must be an https URL")
+	}
+	err = notifier.LookupPublic(ctx, u.Hostname())
+	if errors.Is(err, notifier.ErrNonPublicAddress) {
+		return errors.New("webhookUrl must point to a public host")
+	}
+	if err != nil {
+		return errors.New("webhookUrl host does not resolve")
+	}
+	return nil
+}
+
+const subscribeEmailHTML = `
+	<div style="font-family: Arial, sans-serif; color: #333;">
+		<table style="width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; border-radius: 10px; box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);">
+			<tr>
+				<td style="text-align: center;">
+					<h2 style="color: #333;">Subscribe to %s</h2>
+					<p style="font-size: 16px; color: #555;">Confirm to receive incident and maintenance updates by email.</p>
+					<div style="text-align: center; margin-top: 20px;">
+						<a href="%s" style="background-color: #5cb85c; color: white; padding: 12px 20px; border-radius: 5px; font-size: 16px; text-decoration: none;">
+							Confirm Subscription
+						</a>
+					</div>
+					<p style="font-size: 14px; color: #999; margin-top: 20px;">If you did not request this email you can safely ignore it.</p>
+				</td>
+			</tr>
+		</table>
+	</div>
+`
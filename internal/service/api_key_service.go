@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+)
+
+// APIKeyPrefix marks personal API keys so AuthMiddleware can tell them apart
+// from session JWTs.
+const APIKeyPrefix = "upb_"
+
+// lastUsedResolution limits how often authenticating with a key writes its
+// last-used timestamp.
+const lastUsedResolution = time.Minute
+
+// APIKeyService issues, lists, revokes and authenticates personal API keys.
+type APIKeyService interface {
+	CreateKey(userID uint, name string, scopes []string) (*models.APIKey, string, error)
+	ListKeys(userID uint) ([]models.APIKey, error)
+	RevokeKey(userID, keyID uint) error
+	Authenticate(rawKey string) (*models.APIKey, *models.User, error)
+}
+
+type apiKeyService struct {
+	repo     repository.APIKeyRepository
+	taskRepo repository.TaskRepository
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService.
+func NewAPIKeyService(repo repository.APIKeyRepository, taskRepo repository.TaskRepository) APIKeyService {
+	return &apiKeyService{
+		repo:     repo,
+		taskRepo: taskRepo,
+	}
+}
+
+// CreateKey stores a new key and returns it together with the plaintext
+// secret, which is never retrievable again.
+func (s *apiKeyService) CreateKey(userID uint, name string, scopes []string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("name is required")
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := randomToken(24)
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UserID: userID,
+		Name:   name,
+		Prefix: rawKey[:len(APIKeyPrefix)+8],
+		Hash:   hashToken(rawKey),
+		Scopes: scopes,
+	}
+	if err := s.repo.Create(key); err != nil {
+		return nil, "", err
+	}
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) ListKeys(userID uint) ([]models.APIKey, error) {
+	return s.repo.ListByUserID(userID)
+}
+
+func (s *apiKeyService) RevokeKey(userID, keyID uint) error {
+	key, err := s.repo.FindByID(keyID)
+	if err != nil || key.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	return s.repo.Revoke(key, time.Now())
+}
+
+// Authenticate resolves a plaintext key to its record and owner, rejecting
+// unknown and revoked keys.
+func (s *apiKeyService) Authenticate(rawKey string) (*models.APIKey, *models.User, error) {
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	key, err := s.repo.FindByHash(hashToken(rawKey))
+	if err != nil || key.RevokedAt != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	user, err := s.taskRepo.GetUserByID(key.UserID)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchLastUsed(key.ID, now); err == nil {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, user, nil
+}
+
+// normalizeScopes validates requested scopes, dropping duplicates. A key
+// without scopes is read-only.
+func normalizeScopes(scopes []string) (models.StringList, error) {
+	if len(scopes) == 0 {
+		return models.StringList{models.ScopeRead}, nil
+	}
+	out := models.StringList{}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		valid := false
+		for _, allowed := range models.APIKeyScopes {
+			if scope == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	return out, nil
+}
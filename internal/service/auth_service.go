@@ -1,84 +1,108 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
+	"strings"
+	"time"
+	"upbot-server-go/internal/infrastructure"
 	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/oidc"
 	"upbot-server-go/internal/repository"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/go-redis/redis/v8"
 )
 
 type AuthService interface {
-	LoginWithGoogle(accessToken string) (*models.User, string, error)
+	LoginWithGoogle(ctx context.Context, idToken string) (*models.User, *TokenPair, error)
+	ListProviders() []string
+	BeginOIDCLogin(ctx context.Context, provider string) (authURL, state string, err error)
+	CompleteOIDCLogin(ctx context.Context, provider, state, code string) (*models.User, *TokenPair, error)
+	SendMagicLink(ctx context.Context, email string) error
+	VerifyMagicLink(ctx context.Context, token string) (*models.User, *TokenPair, error)
+	Refresh(refreshToken string) (*TokenPair, error)
+	Logout(claims *AccessClaims, refreshToken string, allSessions bool) error
+	ParseAccessToken(ctx context.Context, token string) (*AccessClaims, error)
+}
+
+// AuthConfig holds the session settings of AuthService.
+type AuthConfig struct {
+	JWTSecret       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// PublicURL is the server's base URL, used in magic links.
+	PublicURL string
 }
 
 type authService struct {
-	repo      repository.TaskRepository // Using TaskRepo as it has User methods
-	jwtSecret string
+	repo        repository.TaskRepository // Using TaskRepo as it has User methods
+	refreshRepo repository.RefreshTokenRepository
+	redisClient *redis.Client
+	emailClient infrastructure.EmailClient
+	google      *oidc.Verifier
+	providers   map[string]LoginProvider
+	jwtSecret   string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	publicURL   string
 }
 
-func NewAuthService(repo repository.TaskRepository, jwtSecret string) AuthService {
+func NewAuthService(repo repository.TaskRepository, refreshRepo repository.RefreshTokenRepository, redisClient *redis.Client, emailClient infrastructure.EmailClient, google *oidc.Verifier, providers []LoginProvider, config AuthConfig) AuthService {
+	byName := make(map[string]LoginProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
 	return &authService{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:        repo,
+		refreshRepo: refreshRepo,
+		redisClient: redisClient,
+		emailClient: emailClient,
+		google:      google,
+		providers:   byName,
+		jwtSecret:   config.JWTSecret,
+		accessTTL:   config.AccessTokenTTL,
+		refreshTTL:  config.RefreshTokenTTL,
+		publicURL:   config.PublicURL,
 	}
 }
 
-type GoogleUserInfo struct {
-	Email string `json:"email"`
-}
-
-func (s *authService) LoginWithGoogle(accessToken string) (*models.User, string, error) {
+// LoginWithGoogle signs in with a Google ID token issued to our client ID.
+func (s *authService) LoginWithGoogle(ctx context.Context, idToken string) (*models.User, *TokenPair, error) {
 	// 1. Verify Google Token
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v1/tokeninfo?access_token=" + accessToken)
+	claims, err := s.google.Verify(ctx, idToken)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to call google api: %w", err)
+		return nil, nil, fmt.Errorf("invalid google id token: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, "", errors.New("invalid google access token")
+	if claims.Email == "" || !bool(claims.EmailVerified) {
+		return nil, nil, errors.New("google account email is not verified")
 	}
+	email := strings.ToLower(claims.Email)
 
-	body, err := io.ReadAll(resp.Body)
+	// 2. Find or Create User
+	user, err := s.findOrCreateUser(email)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	var userInfo GoogleUserInfo
-	if err := json.Unmarshal(body, &userInfo); err != nil {
-		return nil, "", err
+	// 3. Start a session
+	tokens, err := s.issueTokens(user, "")
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// 2. Find or Create User
-	user, err := s.repo.GetUserByEmail(userInfo.Email)
+	return user, tokens, nil
+}
+
+func (s *authService) findOrCreateUser(email string) (*models.User, error) {
+	user, err := s.repo.GetUserByEmail(email)
 	if err != nil {
 		// Assume error means not found, create new user
-		newUser := &models.User{Email: userInfo.Email}
+		newUser := &models.User{Email: email}
 		if err := s.repo.CreateUser(newUser); err != nil {
-			return nil, "", fmt.Errorf("failed to create user: %w", err)
+			return nil, fmt.Errorf("failed to create user: %w", err)
 		}
 		user = newUser
 	}
-
-	// 3. Generate JWT
-	token, err := s.generateJWT(user)
-	if err != nil {
-		return nil, "", err
-	}
-
-	return user, token, nil
-}
-
-func (s *authService) generateJWT(user *models.User) (string, error) {
-	claims := jwt.MapClaims{
-		"userId": user.ID,
-		"email":  user.Email,
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	return user, nil
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"upbot-server-go/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidAccessToken  = errors.New("invalid access token")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+// tokenIssuer is the iss claim of session access tokens. Tokens without it,
+// such as the non-expiring ones issued by the legacy server, are rejected.
+const tokenIssuer = "upbot"
+
+// AccessClaims are the claims carried by a session access token.
+type AccessClaims struct {
+	UserID uint   `json:"userId"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// TokenPair is returned on login and refresh. The refresh token is opaque
+// and single-use; every refresh returns a new one.
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// ParseAccessToken verifies an access token's HS256 signature, issuer and
+// expiry, and rejects tokens revoked by Logout.
+func (s *authService) ParseAccessToken(ctx context.Context, tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil || !token.Valid || claims.ID == "" || claims.UserID == 0 {
+		return nil, ErrInvalidAccessToken
+	}
+
+	revoked, err := s.redisClient.Exists(ctx, revokedTokenKey(claims.ID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if revoked > 0 {
+		return nil, ErrInvalidAccessToken
+	}
+	return claims, nil
+}
+
+// Refresh rotates a refresh token. Presenting a token that was already
+// rotated means it leaked, so the whole session family is revoked.
+func (s *authService) Refresh(refreshToken string) (*TokenPair, error) {
+	record, err := s.refreshRepo.FindByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if record.RevokedAt != nil {
+		s.refreshRepo.RevokeFamily(record.FamilyID, now)
+		return nil, ErrInvalidRefreshToken
+	}
+	if now.After(record.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	consumed, err := s.refreshRepo.Consume(record.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		s.refreshRepo.RevokeFamily(record.FamilyID, now)
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.GetUserByID(record.UserID)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	return s.issueTokens(user, record.FamilyID)
+}
+
+// Logout revokes the presented access token and the session behind the
+// refresh token, or every session of the user when allSessions is set.
+func (s *authService) Logout(claims *AccessClaims, refreshToken string, allSessions bool) error {
+	now := time.Now()
+	if claims.ExpiresAt != nil {
+		if ttl := claims.ExpiresAt.Sub(now); ttl > 0 {
+			if err := s.redisClient.Set(context.Background(), revokedTokenKey(claims.ID), 1, ttl).Err(); err != nil {
+				return fmt.Errorf("failed to revoke access token: %w", err)
+			}
+		}
+	}
+
+	if allSessions {
+		return s.refreshRepo.RevokeAllForUser(claims.UserID, now)
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	record, err := s.refreshRepo.FindByHash(hashToken(refreshToken))
+	if err != nil || record.UserID != claims.UserID {
+		return ErrInvalidRefreshToken
+	}
+	return s.refreshRepo.RevokeFamily(record.FamilyID, now)
+}
+
+// issueTokens signs an access token and stores a new refresh token. An empty
+// familyID starts a new session.
+func (s *authService) issueTokens(user *models.User, familyID string) (*TokenPair, error) {
+	now := time.Now()
+	jti, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	claims := AccessClaims{
+		UserID: user.ID,
+		Email:  user.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   fmt.Sprint(user.ID),
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID == "" {
+		if familyID, err = randomToken(16); err != nil {
+			return nil, err
+		}
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.Create(&models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTTL),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func revokedTokenKey(jti string) string {
+	return "revoked_jti:" + jti
+}
+
+// randomToken returns n random bytes, hex encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is how API keys and refresh tokens are stored at rest.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// fakeRefreshRepo keeps refresh tokens in memory with the same semantics
+// as the database repository.
+type fakeRefreshRepo struct {
+	tokens []*models.RefreshToken
+	// beforeConsume simulates a concurrent refresh winning the race.
+	beforeConsume func(id uint)
+}
+
+func (r *fakeRefreshRepo) Create(token *models.RefreshToken) error {
+	token.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeRefreshRepo) FindByHash(hash string) (*models.RefreshToken, error) {
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			copied := *t
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRefreshRepo) Consume(id uint, at time.Time) (bool, error) {
+	if r.beforeConsume != nil {
+		r.beforeConsume(id)
+	}
+	t := r.tokens[id-1]
+	if t.RevokedAt != nil {
+		return false, nil
+	}
+	t.RevokedAt = &at
+	return true, nil
+}
+
+func (r *fakeRefreshRepo) RevokeFamily(familyID string, at time.Time) error {
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+func (r *fakeRefreshRepo) RevokeAllForUser(userID uint, at time.Time) error {
+	for _, t := range r.tokens {
+		if t.UserID == userID && t.RevokedAt == nil {
+			t.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// fakeUserRepo stores users by email; other TaskRepository methods are not
+// used.
+type fakeUserRepo struct {
+	repository.TaskRepository
+	users map[string]*models.User
+}
+
+func (r *fakeUserRepo) GetUserByID(id uint) (*models.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func newTokenTestService() (*authService, *fakeRefreshRepo, *models.User) {
+	user := &models.User{Email: "a@example.com"}
+	user.ID = 1
+	refresh := &fakeRefreshRepo{}
+	s := &authService{
+		repo:        &fakeUserRepo{users: map[string]*models.User{user.Email: user}},
+		refreshRepo: refresh,
+		jwtSecret:   "test-secret",
+		accessTTL:   15 * time.Minute,
+		refreshTTL:  24 * time.Hour,
+	}
+	return s, refresh, user
+}
+
+func TestRefreshRotation(t *testing.T) {
+	tests := []struct {
+		name string
+		// run returns a token whose family should be revoked afterwards,
+		// if any, and the error of the final refresh.
+		run       func(t *testing.T, s *authService, repo *fakeRefreshRepo, login *TokenPair) (string, error)
+		wantErr   error
+		revokeAll bool
+	}{
+		{
+			name: "rotated token works once",
+			run: func(t *testing.T, s *authService, repo *fakeRefreshRepo, login *TokenPair) (string, error) {
+				_, err := s.Refresh(login.RefreshToken)
+				return "", err
+			},
+		},
+		{
+			name: "replayed token revokes the family",
+			run: func(t *testing.T, s *authService, repo *fakeRefreshRepo, login *TokenPair) (string, error) {
+				next, err := s.Refresh(login.RefreshToken)
+				if err != nil {
+					t.Fatalf("first Refresh() = %v", err)
+				}
+				_, err = s.Refresh(login.RefreshToken)
+				return next.RefreshToken, err
+			},
+			wantErr:   ErrInvalidRefreshToken,
+			revokeAll: true,
+		},
+		{
+			name: "losing a concurrent refresh revokes the family",
+			run: func(t *testing.T, s *authService, repo *fakeRefreshRepo, login *TokenPair) (string, error) {
+				var winner *TokenPair
+				repo.beforeConsume = func(id uint) {
+					repo.beforeConsume = nil
+					var err error
+					if winner, err = s.Refresh(login.RefreshToken); err != nil {
+						t.Fatalf("concurrent Refresh() = %v", err)
+					}
+				}
+				_, err := s.Refresh(login.RefreshToken)
+				return winner.RefreshToken, err
+			},
+			wantErr:   ErrInvalidRefreshToken,
+			revokeAll: true,
+		},
+		{
+			name: "expired token is refused without revoking",
+			run: func(t *testing.T, s *authService, repo *fakeRefreshRepo, login *TokenPair) (string, error) {
+				repo.tokens[0].ExpiresAt = time.Now().Add(-time.Minute)
+				_, err := s.Refresh(login.RefreshToken)
+				return "", err
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+		{
+			name: "unknown token is refused",
+			run: func(t *testing.T, s *authService, repo *fakeRefreshRepo, login *TokenPair) (string, error) {
+				_, err := s.Refresh("not-a-token")
+				return "", err
+			},
+			wantErr: ErrInvalidRefreshToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo, user := newTokenTestService()
+			login, err := s.issueTokens(user, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A second login is a separate family and must survive reuse
+			// detection in the first.
+			other, err := s.issueTokens(user, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			descendant, err := tt.run(t, s, repo, login)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Refresh() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.revokeAll {
+				if _, err := s.Refresh(descendant); !errors.Is(err, ErrInvalidRefreshToken) {
+					t.Fatalf("token issued after the replayed one still works: %v", err)
+				}
+			}
+			if _, err := s.Refresh(other.RefreshToken); err != nil {
+				t.Fatalf("other session was revoked: %v", err)
+			}
+		})
+	}
+}
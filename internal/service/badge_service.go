@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+)
+
+var (
+	ErrBadgeNotFound = errors.New("badge not found")
+	ErrInvalidPeriod = errors.New("period must be one of 24h, 7d, 30d or 90d")
+)
+
+// DefaultBadgePeriod is the uptime window shown when no period is given.
+const DefaultBadgePeriod = "30d"
+
+// BadgeService renders a task's status and uptime for public README badges.
+// Badges are addressed by a random per-task token rather than the task ID so
+// they cannot be enumerated.
+type BadgeService interface {
+	GetBadgeLinks(orgID, taskID uint) (*BadgeLinks, error)
+	RotateToken(orgID, taskID uint) (*BadgeLinks, error)
+	Status(token string) (*Badge, error)
+	Uptime(token, period string) (*Badge, error)
+}
+
+type badgeService struct {
+	taskRepo     repository.TaskRepository
+	statRepo     repository.StatRepository
+	incidentRepo repository.IncidentRepository
+	publicURL    string
+}
+
+// NewBadgeService creates a new instance of BadgeService.
+func NewBadgeService(taskRepo repository.TaskRepository, statRepo repository.StatRepository, incidentRepo repository.IncidentRepository, publicURL string) BadgeService {
+	return &badgeService{
+		taskRepo:     taskRepo,
+		statRepo:     statRepo,
+		incidentRepo: incidentRepo,
+		publicURL:    publicURL,
+	}
+}
+
+// Badge is the label and message pair drawn on a badge. Color is one of
+// "green", "yellow", "red" or "grey"; the handler picks the shade.
+type Badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// BadgeLinks are the embeddable URLs for a task's badges. All fields are
+// empty until an editor issues the first token.
+type BadgeLinks struct {
+	Token     string `json:"token"`
+	StatusURL string `json:"statusUrl"`
+	UptimeURL string `json:"uptimeUrl"`
+	Markdown  string `json:"markdown"`
+}
+
+// GetBadgeLinks returns the task's badge URLs. It never issues a token:
+// viewers and read-only keys may call it, and publishing a monitor is an
+// editor's decision.
+func (s *badgeService) GetBadgeLinks(orgID, taskID uint) (*BadgeLinks, error) {
+	task, err := s.findOwned(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.BadgeToken == nil {
+		return &BadgeLinks{}, nil
+	}
+	return s.links(*task.BadgeToken), nil
+}
+
+// RotateToken issues the task's badge token, replacing any existing one;
+// badges embedded with the old one stop resolving.
+func (s *badgeService) RotateToken(orgID, taskID uint) (*BadgeLinks, error) {
+	task, err := s.findOwned(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issueToken(task)
+}
+
+func (s *badgeService) issueToken(task *models.Task) (*BadgeLinks, error) {
+	token, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.taskRepo.UpdateBadgeToken(task.ID, token); err != nil {
+		return nil, fmt.Errorf("failed to save badge token: %w", err)
+	}
+	return s.links(token), nil
+}
+
+func (s *badgeService) links(token string) *BadgeLinks {
+	base := s.publicURL + "/badge/" + token
+	return &BadgeLinks{
+		Token:     token,
+		StatusURL: base + "/status.svg",
+		UptimeURL: base + "/uptime.svg?period=" + DefaultBadgePeriod,
+		Markdown:  fmt.Sprintf("![status](%s/status.svg) ![uptime](%s/uptime.svg?period=%s)", base, base, DefaultBadgePeriod),
+	}
+}
+
+func (s *badgeService) findOwned(orgID, taskID uint) (*models.Task, error) {
+	task, err := s.taskRepo.FindByID(taskID)
+	if err != nil || task.OrganizationID != orgID {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// Status reports whether the task is up, down or paused.
+func (s *badgeService) Status(token string) (*Badge, error) {
+	task, err := s.taskRepo.FindByBadgeToken(token)
+	if err != nil {
+		return nil, ErrBadgeNotFound
+	}
+
+	badge := &Badge{Label: "status"}
+	if !task.IsActive {
+		badge.Message, badge.Color = "paused", "grey"
+		return badge, nil
+	}
+	open, err := s.incidentRepo.OpenTaskIDs([]uint{task.ID})
+	if err != nil {
+		return nil, err
+	}
+	if open[task.ID] {
+		badge.Message, badge.Color = "down", "red"
+	} else {
+		badge.Message, badge.Color = "up", "green"
+	}
+	return badge, nil
+}
+
+// Uptime reports the task's uptime over one of the stats windows, computed
+// from the same rollups as the stats endpoint.
+func (s *badgeService) Uptime(token, name string) (*Badge, error) {
+	if name == "" {
+		name = DefaultBadgePeriod
+	}
+	var window *statsWindow
+	for i := range statsWindows {
+		if statsWindows[i].name == name {
+			window = &statsWindows[i]
+		}
+	}
+	if window == nil {
+		return nil, ErrInvalidPeriod
+	}
+
+	task, err := s.taskRepo.FindByBadgeToken(token)
+	if err != nil {
+		return nil, ErrBadgeNotFound
+	}
+
+	now := time.Now().UTC()
+	var start time.Time
+	var periods []period
+	if window.daily {
+		start = now.Truncate(24 * time.Hour).Add(-window.duration).Add(24 * time.Hour)
+		days, err := s.statRepo.ListDaily(task.ID, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range days {
+			periods = append(periods, period{d.Day, d.CheckAggregate})
+		}
+	} else {
+		start = now.Add(-window.duration).Truncate(time.Hour)
+		hours, err := s.statRepo.ListHourly(task.ID, start)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hours {
+			periods = append(periods, period{h.Hour, h.CheckAggregate})
+		}
+	}
+
+	badge := &Badge{Label: "uptime " + name, Message: "no data", Color: "grey"}
+	stats := windowStats(name, start, periods, nil)
+	if stats.Uptime == nil {
+		return badge, nil
+	}
+	badge.Message = formatUptime(*stats.Uptime)
+	switch {
+	case *stats.Uptime >= 99.9:
+		badge.Color = "green"
+	case *stats.Uptime >= 95:
+		badge.Color = "yellow"
+	default:
+		badge.Color = "red"
+	}
+	return badge, nil
+}
+
+// formatUptime keeps two decimals but never rounds a partial outage up to
+// 100%.
+func formatUptime(uptime float64) string {
+	if uptime < 100 && uptime > 99.99 {
+		return "99.99%"
+	}
+	return fmt.Sprintf("%.2f%%", uptime)
+}
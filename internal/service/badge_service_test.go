@@ -0,0 +1,44 @@
+package service
+
+import (
+	"testing"
+	"upbot-server-go/internal/models"
+)
+
+func (r *fakeTaskRepo) UpdateBadgeToken(id uint, token string) error {
+	r.tasks[id].BadgeToken = &token
+	return nil
+}
+
+func newBadgeTestService() (*badgeService, *fakeTaskRepo) {
+	task := &models.Task{URL: "https://example.com", OrganizationID: 1}
+	task.ID = 7
+	repo := &fakeTaskRepo{tasks: map[uint]*models.Task{task.ID: task}}
+	return &badgeService{taskRepo: repo, publicURL: "https://upbot.example"}, repo
+}
+
+func TestGetBadgeLinksDoesNotIssueToken(t *testing.T) {
+	s, repo := newBadgeTestService()
+	links, err := s.GetBadgeLinks(1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *links != (BadgeLinks{}) {
+		t.Fatalf("GetBadgeLinks() = %+v, want empty links", links)
+	}
+	if repo.tasks[7].BadgeToken != nil {
+		t.Fatal("GetBadgeLinks() issued a badge token")
+	}
+
+	issued, err := s.RotateToken(1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, err = s.GetBadgeLinks(1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links.Token == "" || links.Token != issued.Token {
+		t.Fatalf("GetBadgeLinks() token = %q, want %q", links.Token, issued.Token)
+	}
+}
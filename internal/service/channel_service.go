@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"upbot-server-go/internal/infrastructure"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/repository"
+)
+
+var ErrChannelNotFound = errors.New("channel not found")
+
+// ChannelService manages an organization's notification channels.
+type ChannelService interface {
+	CreateChannel(orgID, userID uint, req ChannelRequest) (*models.Channel, error)
+	ListChannels(orgID uint) ([]models.Channel, error)
+	UpdateChannel(orgID, channelID uint, req ChannelRequest) (*models.Channel, error)
+	DeleteChannel(orgID, channelID uint) error
+	PreviewTemplate(req TemplatePreviewRequest) (*TemplatePreview, error)
+	TestChannel(orgID, channelID uint) (*notifier.Delivery, error)
+}
+
+type channelService struct {
+	repo         repository.ChannelRepository
+	taskRepo     repository.TaskRepository
+	plans        PlanService
+	emailClient  infrastructure.EmailClient
+	dashboardURL string
+}
+
+// NewChannelService creates a new instance of ChannelService.
+func NewChannelService(repo repository.ChannelRepository, taskRepo repository.TaskRepository, plans PlanService, emailClient infrastructure.EmailClient, dashboardURL string) ChannelService {
+	return &channelService{
+		repo:         repo,
+		taskRepo:     taskRepo,
+		plans:        plans,
+		emailClient:  emailClient,
+		dashboardURL: dashboardURL,
+	}
+}
+
+// ChannelRequest creates or replaces a channel. A nil TaskIDs leaves the
+// monitors linked to the channel unchanged; an empty one unlinks them all.
+type ChannelRequest struct {
+	Name          string
+	Type          string
+	Config        map[string]string
+	TitleTemplate string
+	BodyTemplate  string
+	TaskIDs       *[]uint
+
+	RateLimitPerMinute int
+	GroupWindowSeconds int
+	DigestMode         string
+}
+
+type TemplatePreviewRequest struct {
+	TitleTemplate string
+	BodyTemplate  string
+	Event         notifier.EventType
+}
+
+type TemplatePreview struct {
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Variables map[string]string `json:"variables"`
+}
+
+func (s *channelService) CreateChannel(orgID, userID uint, req ChannelRequest) (*models.Channel, error) {
+	channel := &models.Channel{
+		UserID:         userID,
+		OrganizationID: orgID,
+		Name:           req.Name,
+		Type:           req.Type,
+		Config:         models.JSONMap(req.Config),
+
+		TitleTemplate: req.TitleTemplate,
+		BodyTemplate:  req.BodyTemplate,
+
+		RateLimitPerMinute: req.RateLimitPerMinute,
+		GroupWindowSeconds: req.GroupWindowSeconds,
+		DigestMode:         req.DigestMode,
+	}
+	if err := validateChannel(channel); err != nil {
+		return nil, err
+	}
+	if err := s.plans.CheckChannels(orgID); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(channel); err != nil {
+		return nil, err
+	}
+	if req.TaskIDs != nil {
+		if err := s.attachTasks(channel, *req.TaskIDs); err != nil {
+			return nil, err
+		}
+	}
+	setTaskIDs(channel)
+	return channel, nil
+}
+
+// ListChannels is open to viewers, so secret settings are masked.
+func (s *channelService) ListChannels(orgID uint) ([]models.Channel, error) {
+	channels, err := s.repo.ListByOrgID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range channels {
+		channels[i].Config = notifier.MaskConfig(channels[i].Config)
+		setTaskIDs(&channels[i])
+	}
+	return channels, nil
+}
+
+func (s *channelService) UpdateChannel(orgID, channelID uint, req ChannelRequest) (*models.Channel, error) {
+	channel, err := s.findOwned(orgID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	channel.Name = req.Name
+	channel.Type = req.Type
+	channel.Config = notifier.UnmaskConfig(models.JSONMap(req.Config), channel.Config)
+	channel.TitleTemplate = req.TitleTemplate
+	channel.BodyTemplate = req.BodyTemplate
+	channel.RateLimitPerMinute = req.RateLimitPerMinute
+	channel.GroupWindowSeconds = req.GroupWindowSeconds
+	channel.DigestMode = req.DigestMode
+	if err := validateChannel(channel); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(channel); err != nil {
+		return nil, err
+	}
+	if req.TaskIDs != nil {
+		if err := s.attachTasks(channel, *req.TaskIDs); err != nil {
+			return nil, err
+		}
+	}
+	setTaskIDs(channel)
+	return channel, nil
+}
+
+func (s *channelService) DeleteChannel(orgID, channelID uint) error {
+	channel, err := s.findOwned(orgID, channelID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(channel)
+}
+
+// PreviewTemplate renders templates against a sample event so users can
+// check their wording before saving a channel.
+func (s *channelService) PreviewTemplate(req TemplatePreviewRequest) (*TemplatePreview, error) {
+	eventType := req.Event
+	if eventType == "" {
+		eventType = notifier.EventDown
+	}
+	if eventType != notifier.EventDown && eventType != notifier.EventUp {
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+
+	templates := notifier.Templates{Title: req.TitleTemplate, Body: req.BodyTemplate}
+	event, err := templates.Apply(notifier.SampleEvent(eventType, s.dashboardURL))
+	if err != nil {
+		return nil, err
+	}
+	return &TemplatePreview{
+		Title:     event.Title,
+		Body:      event.Message,
+		Variables: notifier.TemplateVariables,
+	}, nil
+}
+
+// TestChannel sends a sample alert through the channel's real notifier,
+// bypassing grouping and throttling.
+func (s *channelService) TestChannel(orgID, channelID uint) (*notifier.Delivery, error) {
+	channel, err := s.findOwned(orgID, channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := notifier.New(channel, s.emailClient)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return notifier.Deliver(ctx, n, notifier.SampleEvent(notifier.EventDown, s.dashboardURL)), nil
+}
+
+func (s *channelService) findOwned(orgID, channelID uint) (*models.Channel, error) {
+	channel, err := s.repo.FindByID(channelID)
+	if err != nil || channel.OrganizationID != orgID {
+		return nil, ErrChannelNotFound
+	}
+	return channel, nil
+}
+
+// attachTasks links the channel to the given tasks, which must belong to
+// the channel's organization.
+func (s *channelService) attachTasks(channel *models.Channel, taskIDs []uint) error {
+	tasks, err := s.taskRepo.FindByIDsAndOrgID(taskIDs, channel.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if len(tasks) != len(taskIDs) {
+		return errors.New("one or more tasks not found")
+	}
+	channel.Tasks = tasks
+	return s.repo.ReplaceTasks(channel, tasks)
+}
+
+// setTaskIDs fills in the IDs of the linked tasks for the response.
+func setTaskIDs(channel *models.Channel) {
+	channel.TaskIDs = make([]uint, len(channel.Tasks))
+	for i, task := range channel.Tasks {
+		channel.TaskIDs[i] = task.ID
+	}
+}
+
+// validateChannel checks the type and required settings by building the
+// notifier the worker would use.
+func validateChannel(channel *models.Channel) error {
+	if channel.Name == "" {
+		return errors.New("channel name is required")
+	}
+	if channel.DigestMode != "" && channel.DigestMode != models.DigestHourly {
+		return fmt.Errorf("unknown digest mode %q", channel.DigestMode)
+	}
+	if channel.RateLimitPerMinute < 0 {
+		return errors.New("rateLimitPerMinute cannot be negative")
+	}
+	if channel.GroupWindowSeconds < 0 || channel.GroupWindowSeconds > 3600 {
+		return errors.New("groupWindowSeconds must be between 0 and 3600")
+	}
+	// Incident tools get every event as it happens, so that each resolve
+	// finds its trigger; grouping, throttling and digests never apply.
+	if !notifier.SupportsBatching(channel.Type) &&
+		(channel.RateLimitPerMinute != 0 || channel.GroupWindowSeconds != 0 || channel.DigestMode != "") {
+		return fmt.Errorf("%s channels deliver every event immediately and do not support rateLimitPerMinute, groupWindowSeconds or digestMode", channel.Type)
+	}
+	if _, err := notifier.New(channel, nil); err != nil {
+		return fmt.Errorf("invalid channel: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// fakeChannelRepo holds a single channel; other ChannelRepository methods
+// are not used.
+type fakeChannelRepo struct {
+	repository.ChannelRepository
+	channel *models.Channel
+}
+
+func (r *fakeChannelRepo) FindByID(id uint) (*models.Channel, error) {
+	if r.channel == nil || r.channel.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *r.channel
+	return &copied, nil
+}
+
+func (r *fakeChannelRepo) Update(channel *models.Channel) error {
+	tasks := r.channel.Tasks
+	copied := *channel
+	copied.Tasks = tasks
+	r.channel = &copied
+	return nil
+}
+
+func (r *fakeChannelRepo) ReplaceTasks(channel *models.Channel, tasks []models.Task) error {
+	r.channel.Tasks = tasks
+	return nil
+}
+
+func (r *fakeTaskRepo) FindByIDsAndOrgID(ids []uint, orgID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	for _, id := range ids {
+		if t, ok := r.tasks[id]; ok && t.OrganizationID == orgID {
+			tasks = append(tasks, *t)
+		}
+	}
+	return tasks, nil
+}
+
+func TestUpdateChannelTasks(t *testing.T) {
+	taskIDs := func(ids ...uint) *[]uint { return &ids }
+	tests := []struct {
+		name    string
+		taskIDs *[]uint
+		want    []uint
+	}{
+		{"omitted keeps links", nil, []uint{1, 2}},
+		{"replaced", taskIDs(2, 3), []uint{2, 3}},
+		{"empty unlinks all", taskIDs(), []uint{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := map[uint]*models.Task{}
+			var linked []models.Task
+			for _, id := range []uint{1, 2, 3} {
+				task := &models.Task{OrganizationID: 1}
+				task.ID = id
+				tasks[id] = task
+				if id < 3 {
+					linked = append(linked, *task)
+				}
+			}
+			channel := &models.Channel{OrganizationID: 1, Name: "ops", Type: models.ChannelDiscord, Tasks: linked}
+			channel.ID = 5
+			repo := &fakeChannelRepo{channel: channel}
+			s := &channelService{repo: repo, taskRepo: &fakeTaskRepo{tasks: tasks}}
+
+			updated, err := s.UpdateChannel(1, 5, ChannelRequest{
+				Name:    "ops",
+				Type:    models.ChannelDiscord,
+				Config:  map[string]string{"webhookUrl": "https://discord.com/api/webhooks/1/token"},
+				TaskIDs: tt.taskIDs,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(updated.TaskIDs, tt.want) {
+				t.Fatalf("UpdateChannel() taskIds = %v, want %v", updated.TaskIDs, tt.want)
+			}
+			if len(repo.channel.Tasks) != len(tt.want) {
+				t.Fatalf("stored %d linked tasks, want %d", len(repo.channel.Tasks), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestValidateChannelDeliverySettings(t *testing.T) {
+	pagerDuty := func(apply func(*models.Channel)) *models.Channel {
+		channel := &models.Channel{Name: "oncall", Type: models.ChannelPagerDuty, Config: models.JSONMap{"routingKey": "R0123456789"}}
+		apply(channel)
+		return channel
+	}
+	tests := []struct {
+		name    string
+		channel *models.Channel
+		wantErr bool
+	}{
+		{"pagerduty defaults", pagerDuty(func(*models.Channel) {}), false},
+		{"pagerduty rate limit", pagerDuty(func(c *models.Channel) { c.RateLimitPerMinute = 5 }), true},
+		{"pagerduty group window", pagerDuty(func(c *models.Channel) { c.GroupWindowSeconds = 60 }), true},
+		{"pagerduty digest", pagerDuty(func(c *models.Channel) { c.DigestMode = models.DigestHourly }), true},
+		{"opsgenie digest", &models.Channel{Name: "oncall", Type: models.ChannelOpsgenie, Config: models.JSONMap{"apiKey": "key"}, DigestMode: models.DigestHourly}, true},
+		{"discord digest", &models.Channel{Name: "ops", Type: models.ChannelDiscord, Config: models.JSONMap{"webhookUrl": "https://discord.com/api/webhooks/1/t"}, DigestMode: models.DigestHourly}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateChannel(tt.channel); (err != nil) != tt.wantErr {
+				t.Fatalf("validateChannel() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"upbot-server-go/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidMagicLink  = errors.New("login link is invalid or has expired")
+	ErrMagicLinkThrottle = errors.New("too many login links requested, try again later")
+)
+
+const (
+	magicLinkTTL = 15 * time.Minute
+	// magicLinkAudience keeps login links and access tokens from being
+	// accepted in place of each other.
+	magicLinkAudience = "magic-link"
+	// magicLinkLimit caps the links sent to one address per magicLinkTTL.
+	magicLinkLimit = 5
+)
+
+// SendMagicLink emails a signed, single-use login link to the address.
+func (s *authService) SendMagicLink(ctx context.Context, email string) error {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return fmt.Errorf("invalid email address: %w", err)
+	}
+	email = strings.ToLower(addr.Address)
+
+	rateKey := "magic_link_rate:" + email
+	count, err := s.redisClient.Incr(ctx, rateKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check login link rate: %w", err)
+	}
+	if count == 1 {
+		s.redisClient.Expire(ctx, rateKey, magicLinkTTL)
+	}
+	if count > magicLinkLimit {
+		return ErrMagicLinkThrottle
+	}
+
+	jti, err := randomToken(16)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Issuer:    tokenIssuer,
+		Audience:  jwt.ClaimStrings{magicLinkAudience},
+		Subject:   email,
+		ID:        jti,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(magicLinkTTL)),
+	}).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return err
+	}
+
+	link := s.publicURL + "/auth/magic-link/verify?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(magicLinkEmailHTML, html.EscapeString(link), int(magicLinkTTL.Minutes()))
+	return s.emailClient.SendEmail([]string{email}, "Your UpBot login link", body)
+}
+
+// VerifyMagicLink exchanges a login link token for a session, creating the
+// user on first login. Each link works once.
+func (s *authService) VerifyMagicLink(ctx context.Context, tokenString string) (*models.User, *TokenPair, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.jwtSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithAudience(magicLinkAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid || claims.ID == "" || claims.Subject == "" {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	// Mark the link used for as long as it would otherwise stay valid.
+	ttl := time.Until(claims.ExpiresAt.Time)
+	first, err := s.redisClient.SetNX(ctx, "magic_link_used:"+claims.ID, 1, ttl).Result()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to redeem login link: %w", err)
+	}
+	if !first {
+		return nil, nil, ErrInvalidMagicLink
+	}
+
+	user, err := s.findOrCreateUser(claims.Subject)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := s.issueTokens(user, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+const magicLinkEmailHTML = `
+	<div style="font-family: Arial, sans-serif; color: #333;">
+		<table style="width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; border-radius: 10px; box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);">
+			<tr>
+				<td style="text-align: center;">
+					<h2 style="color: #333;">Log in to UpBot</h2>
+					<p style="font-size: 16px; color: #555;">Click the button below to log in. The link expires in %[2]d minutes and can only be used once.</p>
+					<div style="text-align: center; margin-top: 20px;">
+						<a href="%[1]s" style="background-color: #5cb85c; color: white; padding: 12px 20px; border-radius: 5px; font-size: 16px; text-decoration: none;">
+							Log In
+						</a>
+					</div>
+					<p style="font-size: 14px; color: #999; margin-top: 20px;">If you did not request this email you can safely ignore it.</p>
+				</td>
+			</tr>
+		</table>
+	</div>
+`
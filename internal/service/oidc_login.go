@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/oidc"
+
+	"github.com/go-redis/redis/v8"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown login provider")
+	ErrInvalidState    = errors.New("login session expired or invalid")
+	ErrUnverifiedEmail = errors.New("your email is not verified at the identity provider; verify it there or sign in another way")
+)
+
+// LoginStateTTL bounds how long a user may take at the identity provider.
+const LoginStateTTL = 10 * time.Minute
+
+// LoginProvider is an external identity provider using the authorization
+// code flow with PKCE. *oidc.Provider implements it.
+type LoginProvider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (*oidc.IDTokenClaims, error)
+}
+
+// loginState is kept in Redis between the redirect to the provider and the
+// callback.
+type loginState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"codeVerifier"`
+	Nonce        string `json:"nonce"`
+}
+
+func (s *authService) ListProviders() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOIDCLogin stores a fresh state, nonce and PKCE verifier and returns
+// the provider URL to redirect the user to. The caller must bind the
+// returned state to the browser, so that a callback opened in another
+// browser is refused.
+func (s *authService) BeginOIDCLogin(ctx context.Context, name string) (string, string, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state, err := oidc.RandomString(24)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := json.Marshal(loginState{Provider: name, CodeVerifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.redisClient.Set(ctx, loginStateKey(state), data, LoginStateTTL).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store login state: %w", err)
+	}
+	return authURL, state, nil
+}
+
+// CompleteOIDCLogin handles the provider callback. Each state can be used
+// once.
+func (s *authService) CompleteOIDCLogin(ctx context.Context, name, state, code string) (*models.User, *TokenPair, error) {
+	provider, ok := s.providers[name]
+	if !ok {
+		return nil, nil, ErrUnknownProvider
+	}
+	if state == "" || code == "" {
+		return nil, nil, ErrInvalidState
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	get := pipe.Get(ctx, loginStateKey(state))
+	pipe.Del(ctx, loginStateKey(state))
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil, ErrInvalidState
+		}
+		return nil, nil, fmt.Errorf("failed to load login state: %w", err)
+	}
+	var saved loginState
+	if err := json.Unmarshal([]byte(get.Val()), &saved); err != nil || saved.Provider != name {
+		return nil, nil, ErrInvalidState
+	}
+
+	claims, err := provider.Exchange(ctx, code, saved.CodeVerifier, saved.Nonce)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s login failed: %w", name, err)
+	}
+
+	user, err := s.oidcUser(ctx, name, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+	tokens, err := s.issueTokens(user, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// oidcUser finds the account for a provider login by email, or creates
+// one. Accounts are keyed by email alone, so an email the provider has not
+// verified is refused outright: linking on it would let anyone registered
+// there take over the matching account, and creating an account for it
+// would hand its owner's later logins to whoever created it.
+func (s *authService) oidcUser(ctx context.Context, provider string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	if !bool(claims.EmailVerified) {
+		return nil, ErrUnverifiedEmail
+	}
+	email := strings.ToLower(claims.Email)
+	user, err := s.repo.GetUserByEmail(email)
+	if err != nil {
+		// Assume error means not found, create new user
+		user = &models.User{Email: email}
+		if err := s.repo.CreateUser(user); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		return user, nil
+	}
+	slog.InfoContext(ctx, "Linked OIDC login to existing account by email", "provider", provider, "subject", claims.Subject, "user_id", user.ID)
+	return user, nil
+}
+
+func loginStateKey(state string) string {
+	return "oidc_state:" + state
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/oidc"
+
+	"gorm.io/gorm"
+)
+
+func (r *fakeUserRepo) GetUserByEmail(email string) (*models.User, error) {
+	if u, ok := r.users[email]; ok {
+		return u, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) CreateUser(user *models.User) error {
+	user.ID = uint(len(r.users) + 100)
+	r.users[user.Email] = user
+	return nil
+}
+
+func TestOIDCUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		verified bool
+		wantID   uint
+		wantErr  error
+	}{
+		{"verified links existing", "Owner@example.com", true, 1, nil},
+		{"unverified does not link existing", "owner@example.com", false, 0, ErrUnverifiedEmail},
+		{"verified creates new", "new@example.com", true, 101, nil},
+		{"unverified does not create new", "new@example.com", false, 0, ErrUnverifiedEmail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := &models.User{Email: "owner@example.com"}
+			owner.ID = 1
+			repo := &fakeUserRepo{users: map[string]*models.User{owner.Email: owner}}
+			s := &authService{repo: repo}
+			claims := &oidc.IDTokenClaims{Email: tt.email, EmailVerified: oidc.Bool(tt.verified)}
+
+			user, err := s.oidcUser(context.Background(), "keycloak", claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("oidcUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && user.ID != tt.wantID {
+				t.Fatalf("oidcUser() = user %d, want %d", user.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+// TestOIDCUserUnverifiedThenVerified checks that an unverified login for an
+// unused email leaves nothing behind that the email's owner would later be
+// signed in to.
+func TestOIDCUserUnverifiedThenVerified(t *testing.T) {
+	repo := &fakeUserRepo{users: map[string]*models.User{}}
+	s := &authService{repo: repo}
+	ctx := context.Background()
+
+	squatter := &oidc.IDTokenClaims{Email: "victim@example.com", EmailVerified: oidc.Bool(false)}
+	if _, err := s.oidcUser(ctx, "keycloak", squatter); !errors.Is(err, ErrUnverifiedEmail) {
+		t.Fatalf("unverified oidcUser() error = %v, want %v", err, ErrUnverifiedEmail)
+	}
+	if len(repo.users) != 0 {
+		t.Fatalf("unverified login created %d users", len(repo.users))
+	}
+
+	owner := &oidc.IDTokenClaims{Email: "victim@example.com", EmailVerified: oidc.Bool(true)}
+	first, err := s.oidcUser(ctx, "google", owner)
+	if err != nil {
+		t.Fatalf("verified oidcUser() = %v", err)
+	}
+	again, err := s.oidcUser(ctx, "google", owner)
+	if err != nil {
+		t.Fatalf("second verified oidcUser() = %v", err)
+	}
+	if again.ID != first.ID {
+		t.Fatalf("second login = user %d, want %d", again.ID, first.ID)
+	}
+}
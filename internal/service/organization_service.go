@@ -0,0 +1,323 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+	"upbot-server-go/internal/infrastructure"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrMemberNotFound       = errors.New("member not found")
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrForbidden            = errors.New("your role does not allow this action")
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+// OrganizationService manages organizations, memberships and invitations.
+// Role requirements of routes are enforced by middleware.RequireRole; the
+// rules here cover what depends on the target member, such as owners.
+type OrganizationService interface {
+	ResolveMembership(userID, orgID uint) (*models.Membership, error)
+	PersonalOrganization(userID uint) (*models.Organization, error)
+	MigrateOwnership() error
+	ListOrganizations(userID uint) ([]OrganizationSummary, error)
+	CreateOrganization(userID uint, name string) (*models.Organization, error)
+	ListMembers(orgID uint) ([]models.Membership, error)
+	UpdateMemberRole(actor *models.Membership, userID uint, role string) (*models.Membership, error)
+	RemoveMember(actor *models.Membership, userID uint) error
+	InviteMember(actor *models.Membership, email, role string) (*models.Invitation, error)
+	ListInvitations(orgID uint) ([]models.Invitation, error)
+	RevokeInvitation(orgID, invitationID uint) error
+	AcceptInvitation(userID uint, token string) (*models.Membership, error)
+}
+
+type organizationService struct {
+	repo         repository.OrganizationRepository
+	taskRepo     repository.TaskRepository
+	emailClient  infrastructure.EmailClient
+	dashboardURL string
+}
+
+// NewOrganizationService creates a new instance of OrganizationService.
+func NewOrganizationService(repo repository.OrganizationRepository, taskRepo repository.TaskRepository, emailClient infrastructure.EmailClient, dashboardURL string) OrganizationService {
+	return &organizationService{
+		repo:         repo,
+		taskRepo:     taskRepo,
+		emailClient:  emailClient,
+		dashboardURL: dashboardURL,
+	}
+}
+
+// OrganizationSummary is an organization as seen by one of its members.
+type OrganizationSummary struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	Personal bool   `json:"personal"`
+	Role     string `json:"role"`
+}
+
+// ResolveMembership returns the user's membership in orgID, or in their
+// personal organization when orgID is 0.
+func (s *organizationService) ResolveMembership(userID, orgID uint) (*models.Membership, error) {
+	if orgID == 0 {
+		org, err := s.PersonalOrganization(userID)
+		if err != nil {
+			return nil, err
+		}
+		orgID = org.ID
+	}
+	membership, err := s.repo.FindMembership(orgID, userID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return membership, nil
+}
+
+// PersonalOrganization returns the user's personal organization, creating
+// it and moving their existing tasks and channels into it on first use.
+func (s *organizationService) PersonalOrganization(userID uint) (*models.Organization, error) {
+	if org, err := s.repo.FindPersonal(userID); err == nil {
+		return org, nil
+	}
+
+	org := &models.Organization{Name: "Personal", Personal: true}
+	if err := s.repo.Create(org, userID); err != nil {
+		return nil, fmt.Errorf("failed to create personal organization: %w", err)
+	}
+	if err := s.repo.AssignUnowned(userID, org.ID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// MigrateOwnership moves tasks and channels that predate organizations, or
+// were created by the legacy server, into their creator's personal
+// organization.
+func (s *organizationService) MigrateOwnership() error {
+	userIDs, err := s.repo.UsersWithUnowned()
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		org, err := s.PersonalOrganization(userID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.AssignUnowned(userID, org.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *organizationService) ListOrganizations(userID uint) ([]OrganizationSummary, error) {
+	// Make sure the personal organization shows up for new users.
+	if _, err := s.PersonalOrganization(userID); err != nil {
+		return nil, err
+	}
+	memberships, err := s.repo.ListMembershipsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]OrganizationSummary, 0, len(memberships))
+	for _, m := range memberships {
+		if m.Organization == nil {
+			continue
+		}
+		summaries = append(summaries, OrganizationSummary{
+			ID:       m.OrganizationID,
+			Name:     m.Organization.Name,
+			Personal: m.Organization.Personal,
+			Role:     m.Role,
+		})
+	}
+	return summaries, nil
+}
+
+func (s *organizationService) CreateOrganization(userID uint, name string) (*models.Organization, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	org := &models.Organization{Name: name}
+	if err := s.repo.Create(org, userID); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *organizationService) ListMembers(orgID uint) ([]models.Membership, error) {
+	return s.repo.ListMembers(orgID)
+}
+
+// UpdateMemberRole changes a member's role. Only owners may grant or take
+// away the owner role, and the last owner cannot be demoted.
+func (s *organizationService) UpdateMemberRole(actor *models.Membership, userID uint, role string) (*models.Membership, error) {
+	if !models.ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	member, err := s.repo.FindMembership(actor.OrganizationID, userID)
+	if err != nil {
+		return nil, ErrMemberNotFound
+	}
+	if (member.Role == models.RoleOwner || role == models.RoleOwner) && actor.Role != models.RoleOwner {
+		return nil, ErrForbidden
+	}
+	if member.Role == models.RoleOwner && role != models.RoleOwner {
+		if err := s.checkNotLastOwner(actor.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateRole(member, role); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveMember removes a member. Anyone may leave; removing others needs
+// admin, and removing an owner needs owner.
+func (s *organizationService) RemoveMember(actor *models.Membership, userID uint) error {
+	member, err := s.repo.FindMembership(actor.OrganizationID, userID)
+	if err != nil {
+		return ErrMemberNotFound
+	}
+	if member.UserID != actor.UserID {
+		if !models.RoleAtLeast(actor.Role, models.RoleAdmin) {
+			return ErrForbidden
+		}
+		if member.Role == models.RoleOwner && actor.Role != models.RoleOwner {
+			return ErrForbidden
+		}
+	}
+	if member.Role == models.RoleOwner {
+		if err := s.checkNotLastOwner(actor.OrganizationID); err != nil {
+			return err
+		}
+	}
+	return s.repo.DeleteMembership(member)
+}
+
+func (s *organizationService) checkNotLastOwner(orgID uint) error {
+	owners, err := s.repo.CountOwners(orgID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("an organization needs at least one owner")
+	}
+	return nil
+}
+
+// InviteMember emails a single-use invitation link to join the actor's
+// organization with the given role.
+func (s *organizationService) InviteMember(actor *models.Membership, email, role string) (*models.Invitation, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return nil, fmt.Errorf("invalid email address: %w", err)
+	}
+	email = strings.ToLower(addr.Address)
+	if role == "" {
+		role = models.RoleViewer
+	}
+	if !models.ValidRole(role) {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	if role == models.RoleOwner && actor.Role != models.RoleOwner {
+		return nil, ErrForbidden
+	}
+	if user, err := s.taskRepo.GetUserByEmail(email); err == nil {
+		if _, err := s.repo.FindMembership(actor.OrganizationID, user.ID); err == nil {
+			return nil, errors.New("user is already a member")
+		}
+	}
+	org, err := s.repo.FindByID(actor.OrganizationID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	invitation := &models.Invitation{
+		OrganizationID: actor.OrganizationID,
+		Email:          email,
+		Role:           role,
+		TokenHash:      hashToken(token),
+		InvitedByID:    actor.UserID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	link := s.dashboardURL + "/invitations/accept?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf(invitationEmailHTML, html.EscapeString(org.Name), role, html.EscapeString(link))
+	if err := s.emailClient.SendEmail([]string{email}, "You're invited to "+org.Name+" on UpBot", body); err != nil {
+		s.repo.DeleteInvitation(invitation)
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (s *organizationService) ListInvitations(orgID uint) ([]models.Invitation, error) {
+	return s.repo.ListPendingInvitations(orgID)
+}
+
+func (s *organizationService) RevokeInvitation(orgID, invitationID uint) error {
+	invitation, err := s.repo.FindInvitation(invitationID)
+	if err != nil || invitation.OrganizationID != orgID {
+		return ErrInvitationNotFound
+	}
+	return s.repo.DeleteInvitation(invitation)
+}
+
+// AcceptInvitation adds the user to the inviting organization. The
+// invitation must be addressed to the user's email.
+func (s *organizationService) AcceptInvitation(userID uint, token string) (*models.Membership, error) {
+	invitation, err := s.repo.FindInvitationByHash(hashToken(token))
+	if err != nil || invitation.AcceptedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvitationNotFound
+	}
+	user, err := s.taskRepo.GetUserByID(userID)
+	if err != nil || !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, ErrInvitationNotFound
+	}
+	if _, err := s.repo.FindMembership(invitation.OrganizationID, userID); err == nil {
+		return nil, errors.New("you are already a member")
+	}
+
+	if err := s.repo.AcceptInvitation(invitation, userID, time.Now()); err != nil {
+		return nil, ErrInvitationNotFound
+	}
+	return s.repo.FindMembership(invitation.OrganizationID, userID)
+}
+
+const invitationEmailHTML = `
+	<div style="font-family: Arial, sans-serif; color: #333;">
+		<table style="width: 100%%; max-width: 600px; margin: auto; background-color: #f9f9f9; padding: 20px; border-radius: 10px; box-shadow: 0 4px 8px rgba(0, 0, 0, 0.1);">
+			<tr>
+				<td style="text-align: center;">
+					<h2 style="color: #333;">Join %s on UpBot</h2>
+					<p style="font-size: 16px; color: #555;">You have been invited as <strong>%s</strong>. The invitation expires in 7 days.</p>
+					<div style="text-align: center; margin-top: 20px;">
+						<a href="%s" style="background-color: #5cb85c; color: white; padding: 12px 20px; border-radius: 5px; font-size: 16px; text-decoration: none;">
+							Accept Invitation
+						</a>
+					</div>
+				</td>
+			</tr>
+		</table>
+	</div>
+`
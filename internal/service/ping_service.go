@@ -2,56 +2,159 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
 	"upbot-server-go/internal/repository"
 
 	"github.com/go-redis/redis/v8"
 )
 
-// PingService defines the business logic for pings.
+var ErrTaskNotFound = errors.New("task not found")
+
+// PingService defines the business logic for pings. Tasks belong to an
+// organization; every method is scoped to the caller's organization.
 type PingService interface {
-	CreatePing(email string, req CreatePingRequest) (*models.Task, error)
+	CreatePing(orgID, userID uint, req CreatePingRequest) (*models.Task, error)
+	ListPings(orgID uint, req ListPingsRequest) (*PingPage, error)
+	GetPing(orgID, taskID uint) (*models.Task, error)
+	UpdatePing(orgID, taskID uint, req UpdatePingRequest) (*models.Task, error)
+	DeletePing(orgID, taskID uint) error
+	ReactivatePing(orgID, taskID uint) (*models.Task, error)
+	ListLogs(orgID, taskID uint, req ListLogsRequest) (*LogPage, error)
+	TestNotification(orgID, taskID uint) (*notifier.Delivery, error)
 }
 
 type pingService struct {
-	repo        repository.TaskRepository
-	redisClient *redis.Client
+	repo         repository.TaskRepository
+	logRepo      repository.LogRepository
+	incidentRepo repository.IncidentRepository
+	plans        PlanService
+	redisClient  *redis.Client
 }
 
 // NewPingService creates a new instance of PingService.
-func NewPingService(repo repository.TaskRepository, redisClient *redis.Client) PingService {
+func NewPingService(repo repository.TaskRepository, logRepo repository.LogRepository, incidentRepo repository.IncidentRepository, plans PlanService, redisClient *redis.Client) PingService {
 	return &pingService{
-		repo:        repo,
-		redisClient: redisClient,
+		repo:         repo,
+		logRepo:      logRepo,
+		incidentRepo: incidentRepo,
+		plans:        plans,
+		redisClient:  redisClient,
 	}
 }
 
+// CreatePingRequest creates a task; a zero IntervalSeconds picks the longer
+// of DefaultIntervalSeconds and the plan minimum.
 type CreatePingRequest struct {
-	URL     string
-	WebHook string
+	URL             string
+	WebHook         string
+	Tags            []string
+	IntervalSeconds int
+}
+
+// UpdatePingRequest carries a partial update; nil fields are left unchanged
+// and an empty WebHook removes the Discord webhook. A WebHook equal to the
+// masked form returned by GetPing keeps the stored one.
+type UpdatePingRequest struct {
+	URL             *string
+	WebHook         *string
+	IsActive        *bool
+	Tags            []string
+	IntervalSeconds *int
+}
+
+type ListPingsRequest struct {
+	Status string
+	Tag    string
+	Type   string
+	Query  string
+	Sort   string
+	Desc   bool
+	Cursor string
+	Limit  int
+}
+
+// PingSummary is the compact listing view of a task.
+type PingSummary struct {
+	ID            uint       `json:"id"`
+	URL           string     `json:"url"`
+	Type          string     `json:"type"`
+	Tags          []string   `json:"tags"`
+	IsActive      bool       `json:"isActive"`
+	Status        string     `json:"status"`
+	LastCheckedAt *time.Time `json:"lastCheckedAt"`
+	LastRespCode  int        `json:"lastRespCode"`
+	LastLatencyMs *int64     `json:"lastLatencyMs"`
+	Uptime24h     *float64   `json:"uptime24h"`
+}
+
+type PingPage struct {
+	Pings      []PingSummary `json:"pings"`
+	NextCursor string        `json:"nextCursor,omitempty"`
 }
 
-func (s *pingService) CreatePing(email string, req CreatePingRequest) (*models.Task, error) {
+type ListLogsRequest struct {
+	From    *time.Time
+	To      *time.Time
+	Success *bool
+	Cursor  string
+	Limit   int
+}
+
+// LogEntry is a single probe result.
+type LogEntry struct {
+	ID         uint      `json:"id"`
+	Time       time.Time `json:"time"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"statusCode"`
+	LatencyMs  int64     `json:"latencyMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type LogPage struct {
+	Logs       []LogEntry `json:"logs"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func (s *pingService) CreatePing(orgID, userID uint, req CreatePingRequest) (*models.Task, error) {
 	// 1. Get User
-	user, err := s.repo.GetUserByEmail(email)
+	user, err := s.repo.GetUserByID(userID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
 
-	// 2. Check Task Limit (Business Logic)
-	activeCount, err := s.repo.CountActiveTasksByUserID(user.ID)
-	if err != nil {
+	// 2. Check Plan Limits (Business Logic)
+	if err := s.plans.CheckMonitors(orgID); err != nil {
 		return nil, err
 	}
-	if activeCount >= 5 {
-		return nil, errors.New("task limit reached: you can only have 5 active tasks")
+	interval := req.IntervalSeconds
+	if interval == 0 {
+		plan, err := s.plans.PlanFor(orgID)
+		if err != nil {
+			return nil, err
+		}
+		interval = DefaultIntervalSeconds
+		if plan.MinIntervalSeconds > interval {
+			interval = plan.MinIntervalSeconds
+		}
+	}
+	if err := s.plans.CheckTask(orgID, models.TaskTypeHTTP, interval); err != nil {
+		return nil, err
 	}
 
 	// 3. Check Duplicate (Business Logic)
-	existingTask, _ := s.repo.FindByURLAndUserID(req.URL, user.ID)
+	existingTask, _ := s.repo.FindByURLAndOrgID(req.URL, orgID)
 	if existingTask != nil {
 		return nil, errors.New("task already exists for this URL")
 	}
@@ -65,11 +168,15 @@ func (s *pingService) CreatePing(email string, req CreatePingRequest) (*models.T
 	}
 
 	newTask := &models.Task{
-		URL:           req.URL,
-		IsActive:      true,
-		WebHook:       webHook,
-		NotifyDiscord: notifyDiscord,
-		UserID:        user.ID,
+		URL:             req.URL,
+		IsActive:        true,
+		WebHook:         webHook,
+		NotifyDiscord:   notifyDiscord,
+		UserID:          user.ID,
+		OrganizationID:  orgID,
+		Type:            models.TaskTypeHTTP,
+		Tags:            normalizeTags(req.Tags),
+		IntervalSeconds: interval,
 	}
 
 	// 5. Save to DB
@@ -78,16 +185,349 @@ func (s *pingService) CreatePing(email string, req CreatePingRequest) (*models.T
 	}
 
 	// 6. Add to Redis Queue
-	taskMember := fmt.Sprintf("%d|%s", newTask.ID, newTask.URL)
-	err = s.redisClient.ZAdd(context.Background(), "ping_queue", &redis.Z{
+	if err := s.schedule(newTask); err != nil {
+		// Note: In a real system, you might want to rollback the DB creation or have a retry mechanism
+		return nil, fmt.Errorf("failed to schedule task: %w", err)
+	}
+
+	return newTask, nil
+}
+
+func (s *pingService) ListPings(orgID uint, req ListPingsRequest) (*PingPage, error) {
+	opts := repository.TaskListOptions{
+		Status: req.Status,
+		Tag:    req.Tag,
+		Type:   req.Type,
+		Query:  req.Query,
+		SortBy: req.Sort,
+		Desc:   req.Desc,
+		Limit:  clampPageSize(req.Limit),
+	}
+	if opts.SortBy == "" {
+		opts.SortBy = "id"
+	}
+	if req.Cursor != "" {
+		var cursor repository.TaskCursor
+		if err := decodeCursor(req.Cursor, &cursor); err != nil {
+			return nil, err
+		}
+		opts.After = &cursor
+	}
+
+	// Fetch one extra row to know whether there is a next page.
+	opts.Limit++
+	tasks, err := s.repo.ListPage(orgID, opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &PingPage{Pings: []PingSummary{}}
+	if len(tasks) == opts.Limit {
+		tasks = tasks[:len(tasks)-1]
+		page.NextCursor = encodeCursor(taskCursor(tasks[len(tasks)-1], opts.SortBy))
+	}
+
+	summaries, err := s.summarize(tasks)
+	if err != nil {
+		return nil, err
+	}
+	page.Pings = summaries
+	return page, nil
+}
+
+// summarize attaches the latest check and 24h uptime to each task using one
+// query per table for the whole page.
+func (s *pingService) summarize(tasks []models.Task) ([]PingSummary, error) {
+	ids := make([]uint, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	latest, err := s.logRepo.LatestByTaskIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	uptime, err := s.logRepo.UptimeByTaskIDs(ids, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	open, err := s.incidentRepo.OpenTaskIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]PingSummary, 0, len(tasks))
+	for _, t := range tasks {
+		summary := PingSummary{
+			ID:       t.ID,
+			URL:      t.URL,
+			Type:     t.Type,
+			Tags:     t.Tags,
+			IsActive: t.IsActive,
+		}
+		switch {
+		case !t.IsActive:
+			summary.Status = "paused"
+		case open[t.ID]:
+			summary.Status = "down"
+		default:
+			summary.Status = "up"
+		}
+		if l, ok := latest[t.ID]; ok {
+			checkedAt, latency := l.Time, l.TimeTake
+			summary.LastCheckedAt = &checkedAt
+			summary.LastRespCode = l.RespCode
+			summary.LastLatencyMs = &latency
+		}
+		if u, ok := uptime[t.ID]; ok {
+			summary.Uptime24h = &u
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// ListLogs returns the task's check history, newest first.
+func (s *pingService) ListLogs(orgID, taskID uint, req ListLogsRequest) (*LogPage, error) {
+	if _, err := s.findOwned(orgID, taskID); err != nil {
+		return nil, err
+	}
+	if req.From != nil && req.To != nil && !req.From.Before(*req.To) {
+		return nil, errors.New("from must be before to")
+	}
+
+	opts := repository.LogListOptions{
+		From:    req.From,
+		To:      req.To,
+		Success: req.Success,
+		Limit:   clampPageSize(req.Limit),
+	}
+	if req.Cursor != "" {
+		var cursor repository.LogCursor
+		if err := decodeCursor(req.Cursor, &cursor); err != nil {
+			return nil, err
+		}
+		opts.Before = &cursor
+	}
+
+	opts.Limit++
+	logs, err := s.logRepo.ListByTaskID(taskID, opts)
+	if err != nil {
+		return nil, err
+	}
+	page := &LogPage{Logs: make([]LogEntry, 0, len(logs))}
+	if len(logs) == opts.Limit {
+		logs = logs[:len(logs)-1]
+		last := logs[len(logs)-1]
+		page.NextCursor = encodeCursor(repository.LogCursor{Time: last.Time, ID: last.ID})
+	}
+
+	for _, l := range logs {
+		entry := LogEntry{
+			ID:         l.ID,
+			Time:       l.Time,
+			Success:    l.IsSuccess,
+			StatusCode: l.RespCode,
+			LatencyMs:  l.TimeTake,
+		}
+		if !l.IsSuccess {
+			entry.Error = l.LogResponse
+		}
+		page.Logs = append(page.Logs, entry)
+	}
+	return page, nil
+}
+
+func clampPageSize(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
+
+func taskCursor(last models.Task, sortBy string) repository.TaskCursor {
+	cursor := repository.TaskCursor{ID: last.ID}
+	switch sortBy {
+	case "url":
+		cursor.Value = last.URL
+	case "createdAt":
+		cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// encodeCursor turns a repository cursor into an opaque URL-safe token.
+func encodeCursor(cursor interface{}) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(token string, cursor interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(b, cursor); err != nil {
+		return errors.New("invalid cursor")
+	}
+	return nil
+}
+
+// normalizeTags trims, lowercases and de-duplicates tags.
+func normalizeTags(tags []string) models.StringList {
+	seen := make(map[string]bool, len(tags))
+	normalized := models.StringList{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func (s *pingService) GetPing(orgID, taskID uint) (*models.Task, error) {
+	task, err := s.findOwned(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return maskWebHook(task), nil
+}
+
+func (s *pingService) UpdatePing(orgID, taskID uint, req UpdatePingRequest) (*models.Task, error) {
+	task, err := s.findOwned(orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	oldMember := queueMember(task)
+	wasActive := task.IsActive
+
+	if req.URL != nil && *req.URL != task.URL {
+		if existing, _ := s.repo.FindByURLAndOrgID(*req.URL, orgID); existing != nil {
+			return nil, errors.New("task already exists for this URL")
+		}
+		task.URL = *req.URL
+	}
+	if req.WebHook != nil {
+		if *req.WebHook == "" {
+			task.WebHook = nil
+			task.NotifyDiscord = false
+		} else if task.WebHook == nil || *req.WebHook != notifier.MaskSecret(*task.WebHook) {
+			webHook := *req.WebHook
+			task.WebHook = &webHook
+			task.NotifyDiscord = true
+		}
+	}
+	if req.Tags != nil {
+		task.Tags = normalizeTags(req.Tags)
+	}
+	if req.IntervalSeconds != nil && *req.IntervalSeconds != task.IntervalSeconds {
+		if err := s.plans.CheckTask(orgID, task.Type, *req.IntervalSeconds); err != nil {
+			return nil, err
+		}
+		task.IntervalSeconds = *req.IntervalSeconds
+	}
+	if req.IsActive != nil && *req.IsActive != task.IsActive {
+		if *req.IsActive {
+			if err := s.plans.CheckMonitors(orgID); err != nil {
+				return nil, err
+			}
+			// The plan may have changed since the task was paused.
+			if err := s.plans.CheckTask(orgID, task.Type, task.IntervalSeconds); err != nil {
+				return nil, err
+			}
+			task.FailCount = 0
+		}
+		task.IsActive = *req.IsActive
+	}
+
+	if err := s.repo.Update(task); err != nil {
+		return nil, err
+	}
+
+	// The queue member embeds the URL, so any change replaces the entry.
+	ctx := context.Background()
+	if wasActive && (!task.IsActive || queueMember(task) != oldMember) {
+		s.redisClient.ZRem(ctx, "ping_queue", oldMember)
+	}
+	if task.IsActive && (!wasActive || queueMember(task) != oldMember) {
+		if err := s.schedule(task); err != nil {
+			return nil, fmt.Errorf("failed to schedule task: %w", err)
+		}
+	}
+
+	return maskWebHook(task), nil
+}
+
+func (s *pingService) DeletePing(orgID, taskID uint) error {
+	task, err := s.findOwned(orgID, taskID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(task); err != nil {
+		return err
+	}
+	s.redisClient.ZRem(context.Background(), "ping_queue", queueMember(task))
+	return nil
+}
+
+func (s *pingService) ReactivatePing(orgID, taskID uint) (*models.Task, error) {
+	active := true
+	return s.UpdatePing(orgID, taskID, UpdatePingRequest{IsActive: &active})
+}
+
+func (s *pingService) findOwned(orgID, taskID uint) (*models.Task, error) {
+	task, err := s.repo.FindByID(taskID)
+	if err != nil || task.OrganizationID != orgID {
+		return nil, ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// schedule queues the task for its first check in 10 seconds.
+func (s *pingService) schedule(task *models.Task) error {
+	return s.redisClient.ZAdd(context.Background(), "ping_queue", &redis.Z{
 		Score:  float64(time.Now().Add(10 * time.Second).Unix()),
-		Member: taskMember,
+		Member: queueMember(task),
 	}).Err()
+}
+
+// maskWebHook returns a copy of task for responses, with the Discord
+// webhook URL, which lets anyone post to the channel, masked like channel
+// secrets.
+func maskWebHook(task *models.Task) *models.Task {
+	if task.WebHook == nil {
+		return task
+	}
+	masked := *task
+	webHook := notifier.MaskSecret(*task.WebHook)
+	masked.WebHook = &webHook
+	return &masked
+}
 
+func queueMember(task *models.Task) string {
+	return fmt.Sprintf("%d|%s", task.ID, task.URL)
+}
+
+// TestNotification sends a sample alert to the task's legacy Discord webhook.
+func (s *pingService) TestNotification(orgID, taskID uint) (*notifier.Delivery, error) {
+	task, err := s.findOwned(orgID, taskID)
 	if err != nil {
-		// Note: In a real system, you might want to rollback the DB creation or have a retry mechanism
-		return nil, fmt.Errorf("failed to schedule task: %w", err)
+		return nil, err
+	}
+	if task.WebHook == nil || *task.WebHook == "" {
+		return nil, errors.New("task has no webhook configured")
 	}
 
-	return newTask, nil
+	event := notifier.SampleEvent(notifier.EventDown, "")
+	event.TaskID = task.ID
+	event.URL = task.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return notifier.Deliver(ctx, notifier.NewDiscordNotifier(*task.WebHook), event), nil
 }
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// fakeTaskRepo stores tasks by ID; other TaskRepository methods are not
+// used.
+type fakeTaskRepo struct {
+	repository.TaskRepository
+	tasks map[uint]*models.Task
+}
+
+func (r *fakeTaskRepo) FindByID(id uint) (*models.Task, error) {
+	if t, ok := r.tasks[id]; ok {
+		copied := *t
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeTaskRepo) Update(task *models.Task) error {
+	copied := *task
+	r.tasks[task.ID] = &copied
+	return nil
+}
+
+func TestPingWebHookMasking(t *testing.T) {
+	const secret = "https://discord.com/api/webhooks/123/secret-token-abcd"
+	newService := func() (*pingService, *fakeTaskRepo) {
+		webHook := secret
+		task := &models.Task{URL: "https://example.com", OrganizationID: 1, WebHook: &webHook, NotifyDiscord: true}
+		task.ID = 7
+		repo := &fakeTaskRepo{tasks: map[uint]*models.Task{task.ID: task}}
+		return &pingService{repo: repo}, repo
+	}
+	masked := "****abcd"
+
+	t.Run("viewer sees masked webhook", func(t *testing.T) {
+		s, _ := newService()
+		task, err := s.GetPing(1, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.WebHook == nil || *task.WebHook != masked {
+			t.Fatalf("GetPing() webHook = %v, want %q", task.WebHook, masked)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		webHook string
+		want    string
+	}{
+		{"masked value keeps stored webhook", masked, secret},
+		{"new value replaces webhook", "https://discord.com/api/webhooks/9/other", "https://discord.com/api/webhooks/9/other"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newService()
+			webHook := tt.webHook
+			task, err := s.UpdatePing(1, 7, UpdatePingRequest{WebHook: &webHook})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored := repo.tasks[7].WebHook; stored == nil || *stored != tt.want {
+				t.Fatalf("stored webHook = %v, want %q", stored, tt.want)
+			}
+			if want := notifier.MaskSecret(tt.want); task.WebHook == nil || *task.WebHook != want {
+				t.Fatalf("UpdatePing() webHook = %v, want %q", task.WebHook, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+)
+
+var ErrQuotaExceeded = errors.New("plan limit reached")
+
+const (
+	// MinIntervalSeconds is the shortest check interval any plan can allow.
+	MinIntervalSeconds = 30
+	// DefaultIntervalSeconds is used when a task does not ask for one.
+	DefaultIntervalSeconds = 600
+
+	planCacheTTL = time.Minute
+)
+
+// DefaultPlans are created at startup unless plans with the same names
+// exist. "free" keeps the limits UpBot always had; "unlimited" suits
+// self-hosted servers.
+var DefaultPlans = []models.Plan{
+	{Name: "free", MaxMonitors: 5, MinIntervalSeconds: 600},
+	{Name: "unlimited"},
+}
+
+// PlanService resolves the plan of an organization and checks its limits.
+// Plans change rarely, so lookups are cached for a minute.
+type PlanService interface {
+	EnsureDefaults() error
+	DefaultPlanID() uint
+	PlanFor(orgID uint) (*models.Plan, error)
+	CheckMonitors(orgID uint) error
+	CheckChannels(orgID uint) error
+	CheckTask(orgID uint, taskType string, intervalSeconds int) error
+	Usage(orgID uint) (*Usage, error)
+}
+
+type planService struct {
+	repo             repository.PlanRepository
+	taskRepo         repository.TaskRepository
+	defaultPlan      string
+	logRetentionDays int
+
+	mu            sync.Mutex
+	defaultPlanID uint
+	cache         map[uint]cachedPlan
+}
+
+type cachedPlan struct {
+	plan    *models.Plan
+	expires time.Time
+}
+
+// NewPlanService creates a new instance of PlanService. defaultPlan names the
+// plan of organizations and users without one, and logRetentionDays is the
+// server-wide raw log retention that plans can only shorten.
+func NewPlanService(repo repository.PlanRepository, taskRepo repository.TaskRepository, defaultPlan string, logRetentionDays int) PlanService {
+	return &planService{
+		repo:             repo,
+		taskRepo:         taskRepo,
+		defaultPlan:      defaultPlan,
+		logRetentionDays: logRetentionDays,
+		cache:            make(map[uint]cachedPlan),
+	}
+}
+
+// Usage is what an organization uses against its plan. Zero limits mean
+// unlimited.
+type Usage struct {
+	Plan               string   `json:"plan"`
+	Monitors           int64    `json:"monitors"`
+	MaxMonitors        int      `json:"maxMonitors"`
+	Channels           int64    `json:"channels"`
+	MaxChannels        int      `json:"maxChannels"`
+	MinIntervalSeconds int      `json:"minIntervalSeconds"`
+	LogRetentionDays   int      `json:"logRetentionDays"`
+	CheckTypes         []string `json:"checkTypes"`
+}
+
+// EnsureDefaults creates the built-in plans and resolves the default plan,
+// which must exist.
+func (s *planService) EnsureDefaults() error {
+	for _, plan := range DefaultPlans {
+		plan := plan
+		if err := s.repo.FirstOrCreate(&plan); err != nil {
+			return fmt.Errorf("failed to create plan %s: %w", plan.Name, err)
+		}
+	}
+	plan, err := s.repo.FindByName(s.defaultPlan)
+	if err != nil {
+		return fmt.Errorf("default plan %q not found", s.defaultPlan)
+	}
+
+	s.mu.Lock()
+	s.defaultPlanID = plan.ID
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *planService) DefaultPlanID() uint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.defaultPlanID
+}
+
+func (s *planService) PlanFor(orgID uint) (*models.Plan, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[orgID]
+	defaultPlanID := s.defaultPlanID
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.plan, nil
+	}
+
+	plan, err := s.repo.FindForOrganization(orgID, defaultPlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load plan: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache[orgID] = cachedPlan{plan: plan, expires: time.Now().Add(planCacheTTL)}
+	s.mu.Unlock()
+	return plan, nil
+}
+
+// CheckMonitors fails when the organization cannot have another active task.
+func (s *planService) CheckMonitors(orgID uint) error {
+	plan, err := s.PlanFor(orgID)
+	if err != nil {
+		return err
+	}
+	if plan.MaxMonitors == 0 {
+		return nil
+	}
+	activeCount, err := s.taskRepo.CountActiveTasksByOrgID(orgID)
+	if err != nil {
+		return err
+	}
+	if activeCount >= int64(plan.MaxMonitors) {
+		return fmt.Errorf("%w: the %s plan allows %d active tasks", ErrQuotaExceeded, plan.Name, plan.MaxMonitors)
+	}
+	return nil
+}
+
+// CheckChannels fails when the organization cannot add another channel.
+func (s *planService) CheckChannels(orgID uint) error {
+	plan, err := s.PlanFor(orgID)
+	if err != nil {
+		return err
+	}
+	if plan.MaxChannels == 0 {
+		return nil
+	}
+	count, err := s.repo.CountChannels(orgID)
+	if err != nil {
+		return err
+	}
+	if count >= int64(plan.MaxChannels) {
+		return fmt.Errorf("%w: the %s plan allows %d channels", ErrQuotaExceeded, plan.Name, plan.MaxChannels)
+	}
+	return nil
+}
+
+// CheckTask fails when the plan does not allow the task's type or interval.
+func (s *planService) CheckTask(orgID uint, taskType string, intervalSeconds int) error {
+	if intervalSeconds < MinIntervalSeconds {
+		return fmt.Errorf("interval must be at least %d seconds", MinIntervalSeconds)
+	}
+	plan, err := s.PlanFor(orgID)
+	if err != nil {
+		return err
+	}
+	if !plan.AllowsType(taskType) {
+		return fmt.Errorf("%w: the %s plan does not include %s checks", ErrQuotaExceeded, plan.Name, taskType)
+	}
+	if intervalSeconds < plan.MinIntervalSeconds {
+		return fmt.Errorf("%w: the %s plan checks at most every %d seconds", ErrQuotaExceeded, plan.Name, plan.MinIntervalSeconds)
+	}
+	return nil
+}
+
+func (s *planService) Usage(orgID uint) (*Usage, error) {
+	plan, err := s.PlanFor(orgID)
+	if err != nil {
+		return nil, err
+	}
+	monitors, err := s.taskRepo.CountActiveTasksByOrgID(orgID)
+	if err != nil {
+		return nil, err
+	}
+	channels, err := s.repo.CountChannels(orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	retention := s.logRetentionDays
+	if plan.LogRetentionDays > 0 && plan.LogRetentionDays < retention {
+		retention = plan.LogRetentionDays
+	}
+	// Raw logs are always kept until their daily rollup is written.
+	if retention < 2 {
+		retention = 2
+	}
+	minInterval := plan.MinIntervalSeconds
+	if minInterval < MinIntervalSeconds {
+		minInterval = MinIntervalSeconds
+	}
+	checkTypes := []string(plan.CheckTypes)
+	if checkTypes == nil {
+		checkTypes = []string{}
+	}
+
+	return &Usage{
+		Plan:               plan.Name,
+		Monitors:           monitors,
+		MaxMonitors:        plan.MaxMonitors,
+		Channels:           channels,
+		MaxChannels:        plan.MaxChannels,
+		MinIntervalSeconds: minInterval,
+		LogRetentionDays:   retention,
+		CheckTypes:         checkTypes,
+	}, nil
+}
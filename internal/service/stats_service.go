@@ -0,0 +1,265 @@
+package service
+
+import (
+	"math"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+)
+
+// StatsService reports uptime, incident and latency figures for a task.
+type StatsService interface {
+	GetStats(orgID, taskID uint) (*TaskStats, error)
+}
+
+type statsService struct {
+	taskRepo     repository.TaskRepository
+	statRepo     repository.StatRepository
+	incidentRepo repository.IncidentRepository
+}
+
+// NewStatsService creates a new instance of StatsService.
+func NewStatsService(taskRepo repository.TaskRepository, statRepo repository.StatRepository, incidentRepo repository.IncidentRepository) StatsService {
+	return &statsService{
+		taskRepo:     taskRepo,
+		statRepo:     statRepo,
+		incidentRepo: incidentRepo,
+	}
+}
+
+// WindowStats summarises a trailing window. Pointer fields are nil when the
+// window has no data.
+type WindowStats struct {
+	Window       string   `json:"window"`
+	Checks       int64    `json:"checks"`
+	Failures     int64    `json:"failures"`
+	Uptime       *float64 `json:"uptime"`
+	Incidents    int      `json:"incidents"`
+	MTTRSeconds  *float64 `json:"mttrSeconds"`
+	LatencyAvgMs *float64 `json:"latencyAvgMs"`
+	LatencyP50Ms *float64 `json:"latencyP50Ms"`
+	LatencyP95Ms *float64 `json:"latencyP95Ms"`
+	LatencyP99Ms *float64 `json:"latencyP99Ms"`
+}
+
+// DayStats is one bar of the 90-day status strip.
+type DayStats struct {
+	Date      string   `json:"date"`
+	Checks    int64    `json:"checks"`
+	Failures  int64    `json:"failures"`
+	Uptime    *float64 `json:"uptime"`
+	Incidents int      `json:"incidents"`
+}
+
+type TaskStats struct {
+	TaskID  uint          `json:"taskId"`
+	Windows []WindowStats `json:"windows"`
+	Days    []DayStats    `json:"days"`
+}
+
+// Short windows are computed from hourly rollups, long ones from daily
+// rollups since hourly rows are pruned after HOURLY_RETENTION_DAYS.
+type statsWindow struct {
+	name     string
+	duration time.Duration
+	daily    bool
+}
+
+var statsWindows = []statsWindow{
+	{"24h", 24 * time.Hour, false},
+	{"7d", 7 * 24 * time.Hour, false},
+	{"30d", 30 * 24 * time.Hour, true},
+	{"90d", 90 * 24 * time.Hour, true},
+}
+
+const statsDays = 90
+
+func (s *statsService) GetStats(orgID, taskID uint) (*TaskStats, error) {
+	task, err := s.taskRepo.FindByID(taskID)
+	if err != nil || task.OrganizationID != orgID {
+		return nil, ErrTaskNotFound
+	}
+
+	now := time.Now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(statsDays - 1))
+
+	hours, err := s.statRepo.ListHourly(taskID, now.Add(-7*24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.statRepo.ListDaily(taskID, since)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := s.incidentRepo.ListByTaskID(taskID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &TaskStats{TaskID: taskID}
+	for _, w := range statsWindows {
+		var start time.Time
+		var periods []period
+		if w.daily {
+			start = today.Add(-w.duration).Add(24 * time.Hour)
+			for _, d := range days {
+				periods = append(periods, period{d.Day, d.CheckAggregate})
+			}
+		} else {
+			start = now.Add(-w.duration).Truncate(time.Hour)
+			for _, h := range hours {
+				periods = append(periods, period{h.Hour, h.CheckAggregate})
+			}
+		}
+		stats.Windows = append(stats.Windows, windowStats(w.name, start, periods, incidents))
+	}
+	stats.Days = dailySeries(since, statsDays, days, incidents)
+	return stats, nil
+}
+
+// period is an hourly or daily rollup row.
+type period struct {
+	start time.Time
+	agg   models.CheckAggregate
+}
+
+func windowStats(name string, start time.Time, periods []period, incidents []models.Incident) WindowStats {
+	ws := WindowStats{Window: name}
+
+	var agg models.CheckAggregate
+	var rows int
+	for _, p := range periods {
+		if !p.start.Before(start) {
+			mergeStat(&agg, p.agg)
+			rows++
+		}
+	}
+	ws.Checks = agg.Checks
+	ws.Failures = agg.Failures
+	if agg.Checks > 0 {
+		ws.Uptime = ratio(agg.Checks-agg.Failures, agg.Checks)
+		avg := float64(agg.LatencySum) / float64(agg.Checks)
+		ws.LatencyAvgMs = &avg
+		if rows == 1 {
+			// A single period carries exact percentiles.
+			ws.LatencyP50Ms, ws.LatencyP95Ms, ws.LatencyP99Ms = &agg.LatencyP50, &agg.LatencyP95, &agg.LatencyP99
+		} else {
+			ws.LatencyP50Ms = percentile(agg, 0.50)
+			ws.LatencyP95Ms = percentile(agg, 0.95)
+			ws.LatencyP99Ms = percentile(agg, 0.99)
+		}
+	}
+
+	var resolved int
+	var repair time.Duration
+	for _, inc := range incidents {
+		if inc.StartedAt.Before(start) {
+			continue
+		}
+		ws.Incidents++
+		if inc.ResolvedAt != nil {
+			resolved++
+			repair += inc.ResolvedAt.Sub(inc.StartedAt)
+		}
+	}
+	if resolved > 0 {
+		mttr := repair.Seconds() / float64(resolved)
+		ws.MTTRSeconds = &mttr
+	}
+	return ws
+}
+
+func dailySeries(start time.Time, days int, rollups []models.DailyStat, incidents []models.Incident) []DayStats {
+	series := make([]DayStats, days)
+	first := start.Truncate(24 * time.Hour)
+	for i := range series {
+		series[i].Date = first.AddDate(0, 0, i).Format("2006-01-02")
+	}
+
+	dayIndex := func(t time.Time) int {
+		return int(t.UTC().Sub(first) / (24 * time.Hour))
+	}
+	for _, d := range rollups {
+		if i := dayIndex(d.Day); i >= 0 && i < days {
+			series[i].Checks += d.Checks
+			series[i].Failures += d.Failures
+		}
+	}
+	for _, inc := range incidents {
+		if i := dayIndex(inc.StartedAt); i >= 0 && i < days {
+			series[i].Incidents++
+		}
+	}
+	for i := range series {
+		if series[i].Checks > 0 {
+			series[i].Uptime = ratio(series[i].Checks-series[i].Failures, series[i].Checks)
+		}
+	}
+	return series
+}
+
+func mergeStat(dst *models.CheckAggregate, src models.CheckAggregate) {
+	if src.Checks == 0 {
+		return
+	}
+	if dst.Checks == 0 || src.LatencyMin < dst.LatencyMin {
+		dst.LatencyMin = src.LatencyMin
+	}
+	if src.LatencyMax > dst.LatencyMax {
+		dst.LatencyMax = src.LatencyMax
+	}
+	if dst.Checks == 0 {
+		dst.LatencyP50, dst.LatencyP95, dst.LatencyP99 = src.LatencyP50, src.LatencyP95, src.LatencyP99
+	}
+	dst.Checks += src.Checks
+	dst.Failures += src.Failures
+	dst.LatencySum += src.LatencySum
+	if len(dst.LatencyBuckets) != len(models.LatencyBucketBounds)+1 {
+		dst.LatencyBuckets = make(models.Int64List, len(models.LatencyBucketBounds)+1)
+	}
+	for i := range src.LatencyBuckets {
+		if i < len(dst.LatencyBuckets) {
+			dst.LatencyBuckets[i] += src.LatencyBuckets[i]
+		}
+	}
+}
+
+// percentile estimates a latency quantile from the histogram by linear
+// interpolation inside the bucket that contains it.
+func percentile(agg models.CheckAggregate, q float64) *float64 {
+	var total int64
+	for _, c := range agg.LatencyBuckets {
+		total += c
+	}
+	if total == 0 {
+		return nil
+	}
+
+	rank := q * float64(total)
+	var cumulative int64
+	for i, c := range agg.LatencyBuckets {
+		if c == 0 || float64(cumulative+c) < rank {
+			cumulative += c
+			continue
+		}
+		lower := float64(0)
+		if i > 0 {
+			lower = float64(models.LatencyBucketBounds[i-1])
+		}
+		upper := float64(agg.LatencyMax)
+		if i < len(models.LatencyBucketBounds) {
+			upper = float64(models.LatencyBucketBounds[i])
+		}
+		value := lower + (upper-lower)*(rank-float64(cumulative))/float64(c)
+		value = math.Max(float64(agg.LatencyMin), math.Min(float64(agg.LatencyMax), value))
+		return &value
+	}
+	max := float64(agg.LatencyMax)
+	return &max
+}
+
+func ratio(part, total int64) *float64 {
+	r := float64(part) * 100 / float64(total)
+	return &r
+}
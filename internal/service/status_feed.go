@@ -0,0 +1,101 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"upbot-server-go/internal/notifier"
+)
+
+// AtomFeed is an RFC 4287 feed of a status page's announcement updates.
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  AtomPerson  `xml:"author"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type AtomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    AtomLink    `xml:"link"`
+	Content AtomContent `xml:"content"`
+}
+
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+const maxFeedEntries = 50
+
+// Feed returns one entry per announcement update, newest first.
+func (s *announcementService) Feed(slug string) (*AtomFeed, error) {
+	page, err := s.pageRepo.FindBySlug(strings.ToLower(slug))
+	if err != nil {
+		return nil, ErrStatusPageNotFound
+	}
+	announcements, err := s.repo.ListByPageID(page.ID, time.Now().AddDate(0, 0, -announcementDays), maxAnnouncements)
+	if err != nil {
+		return nil, err
+	}
+
+	pageURL := s.pageURL(page)
+	feed := &AtomFeed{
+		ID:      pageURL,
+		Title:   page.Title + " Status",
+		Updated: page.UpdatedAt.UTC().Format(time.RFC3339),
+		Author:  AtomPerson{Name: page.Title},
+		Links: []AtomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: pageURL + "/feed.atom"},
+			{Rel: "alternate", Type: "text/html", Href: pageURL},
+		},
+	}
+
+	var latest time.Time
+	for _, a := range announcements {
+		for _, u := range a.Updates {
+			body := u.Message
+			if a.ScheduledStart != nil && a.ScheduledEnd != nil {
+				body = fmt.Sprintf("Scheduled %s to %s.\n\n%s",
+					a.ScheduledStart.UTC().Format(time.RFC1123), a.ScheduledEnd.UTC().Format(time.RFC1123), body)
+			}
+			feed.Entries = append(feed.Entries, AtomEntry{
+				ID:      fmt.Sprintf("%s#update-%d", pageURL, u.ID),
+				Title:   fmt.Sprintf("%s: %s", notifier.StatusLabel(u.Status), a.Title),
+				Updated: u.CreatedAt.UTC().Format(time.RFC3339),
+				Link:    AtomLink{Rel: "alternate", Type: "text/html", Href: pageURL},
+				Content: AtomContent{Type: "text", Body: body},
+			})
+			if u.CreatedAt.After(latest) {
+				latest = u.CreatedAt
+			}
+		}
+	}
+	sort.SliceStable(feed.Entries, func(i, j int) bool {
+		return feed.Entries[i].Updated > feed.Entries[j].Updated
+	})
+	if len(feed.Entries) > maxFeedEntries {
+		feed.Entries = feed.Entries[:maxFeedEntries]
+	}
+	if latest.After(page.UpdatedAt) {
+		feed.Updated = latest.UTC().Format(time.RFC3339)
+	}
+	return feed, nil
+}
@@ -0,0 +1,420 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/repository"
+)
+
+var ErrStatusPageNotFound = errors.New("status page not found")
+
+var (
+	slugPattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+	colorPattern = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+)
+
+const (
+	// statusIncidentDays is how far back a public page lists incidents.
+	statusIncidentDays = 30
+	maxStatusIncidents = 20
+	maxStatusMonitors  = 50
+)
+
+// Overall states of a public status page.
+const (
+	StatusOperational   = "operational"
+	StatusMaintenance   = "under_maintenance"
+	StatusPartialOutage = "partial_outage"
+	StatusMajorOutage   = "major_outage"
+)
+
+// StatusPageService manages an organization's status pages and builds their
+// public view.
+type StatusPageService interface {
+	CreateStatusPage(orgID uint, req StatusPageRequest) (*models.StatusPage, error)
+	ListStatusPages(orgID uint) ([]models.StatusPage, error)
+	GetStatusPage(orgID, pageID uint) (*models.StatusPage, error)
+	UpdateStatusPage(orgID, pageID uint, req StatusPageRequest) (*models.StatusPage, error)
+	DeleteStatusPage(orgID, pageID uint) error
+	PublicStatus(slug string) (*PublicStatusPage, error)
+}
+
+type statusPageService struct {
+	repo             repository.StatusPageRepository
+	taskRepo         repository.TaskRepository
+	statRepo         repository.StatRepository
+	incidentRepo     repository.IncidentRepository
+	announcementRepo repository.AnnouncementRepository
+}
+
+// NewStatusPageService creates a new instance of StatusPageService.
+func NewStatusPageService(repo repository.StatusPageRepository, taskRepo repository.TaskRepository, statRepo repository.StatRepository, incidentRepo repository.IncidentRepository, announcementRepo repository.AnnouncementRepository) StatusPageService {
+	return &statusPageService{
+		repo:             repo,
+		taskRepo:         taskRepo,
+		statRepo:         statRepo,
+		incidentRepo:     incidentRepo,
+		announcementRepo: announcementRepo,
+	}
+}
+
+type StatusPageRequest struct {
+	Slug        string
+	Title       string
+	Description string
+	LogoURL     string
+	AccentColor string
+	ShowURLs    bool
+	Monitors    []StatusPageMonitorRequest
+}
+
+// StatusPageMonitorRequest lists a task on the page. Monitors are shown in
+// request order; each group appears where its first monitor is listed.
+type StatusPageMonitorRequest struct {
+	TaskID uint
+	Name   string
+	Group  string
+}
+
+// PublicStatusPage is what visitors of a status page see. It never carries
+// task URLs unless the page opts in.
+type PublicStatusPage struct {
+	Slug          string               `json:"slug"`
+	Title         string               `json:"title"`
+	Description   string               `json:"description,omitempty"`
+	LogoURL       string               `json:"logoUrl,omitempty"`
+	AccentColor   string               `json:"accentColor,omitempty"`
+	Status        string               `json:"status"`
+	Announcements []PublicAnnouncement `json:"announcements"`
+	Groups        []StatusGroup        `json:"groups"`
+	Incidents     []PublicIncident     `json:"incidents"`
+	GeneratedAt   time.Time            `json:"generatedAt"`
+}
+
+type StatusGroup struct {
+	Name     string          `json:"name,omitempty"`
+	Monitors []PublicMonitor `json:"monitors"`
+}
+
+type PublicMonitor struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name"`
+	URL       string     `json:"url,omitempty"`
+	Status    string     `json:"status"`
+	Uptime90d *float64   `json:"uptime90d"`
+	Days      []DayStats `json:"days"`
+}
+
+// PublicAnnouncement is an incident update thread or maintenance notice,
+// newest update first.
+type PublicAnnouncement struct {
+	Kind           string                     `json:"kind"`
+	Title          string                     `json:"title"`
+	Status         string                     `json:"status"`
+	ScheduledStart *time.Time                 `json:"scheduledStart,omitempty"`
+	ScheduledEnd   *time.Time                 `json:"scheduledEnd,omitempty"`
+	ClosedAt       *time.Time                 `json:"closedAt"`
+	Updates        []PublicAnnouncementUpdate `json:"updates"`
+}
+
+type PublicAnnouncementUpdate struct {
+	Status   string    `json:"status"`
+	Message  string    `json:"message"`
+	PostedAt time.Time `json:"postedAt"`
+}
+
+// PublicIncident leaves out the failure cause, which usually quotes the URL.
+type PublicIncident struct {
+	Monitor    string     `json:"monitor"`
+	StartedAt  time.Time  `json:"startedAt"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+	RespCode   int        `json:"respCode,omitempty"`
+}
+
+func (s *statusPageService) CreateStatusPage(orgID uint, req StatusPageRequest) (*models.StatusPage, error) {
+	page := &models.StatusPage{OrganizationID: orgID}
+	if err := s.apply(page, req); err != nil {
+		return nil, err
+	}
+	if existing, _ := s.repo.FindBySlug(page.Slug); existing != nil {
+		return nil, errors.New("slug is already taken")
+	}
+	if err := s.repo.Create(page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *statusPageService) ListStatusPages(orgID uint) ([]models.StatusPage, error) {
+	return s.repo.ListByOrgID(orgID)
+}
+
+func (s *statusPageService) GetStatusPage(orgID, pageID uint) (*models.StatusPage, error) {
+	return s.findOwned(orgID, pageID)
+}
+
+func (s *statusPageService) UpdateStatusPage(orgID, pageID uint, req StatusPageRequest) (*models.StatusPage, error) {
+	page, err := s.findOwned(orgID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	oldSlug := page.Slug
+	if err := s.apply(page, req); err != nil {
+		return nil, err
+	}
+	if page.Slug != oldSlug {
+		if existing, _ := s.repo.FindBySlug(page.Slug); existing != nil {
+			return nil, errors.New("slug is already taken")
+		}
+	}
+	if err := s.repo.Update(page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *statusPageService) DeleteStatusPage(orgID, pageID uint) error {
+	page, err := s.findOwned(orgID, pageID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(page)
+}
+
+func (s *statusPageService) findOwned(orgID, pageID uint) (*models.StatusPage, error) {
+	page, err := s.repo.FindByID(pageID)
+	if err != nil || page.OrganizationID != orgID {
+		return nil, ErrStatusPageNotFound
+	}
+	return page, nil
+}
+
+// apply validates the request and copies it onto the page. Listed tasks
+// must belong to the page's organization.
+func (s *statusPageService) apply(page *models.StatusPage, req StatusPageRequest) error {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !slugPattern.MatchString(slug) {
+		return errors.New("slug must be 2-63 lowercase letters, digits or dashes")
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return errors.New("title is required")
+	}
+	if req.LogoURL != "" {
+		u, err := url.Parse(req.LogoURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("logoUrl must be an http(s) URL")
+		}
+	}
+	if req.AccentColor != "" && !colorPattern.MatchString(req.AccentColor) {
+		return errors.New("accentColor must be a hex colour such as #22c55e")
+	}
+	if len(req.Monitors) > maxStatusMonitors {
+		return fmt.Errorf("a status page can show at most %d monitors", maxStatusMonitors)
+	}
+
+	taskIDs := make([]uint, 0, len(req.Monitors))
+	seen := make(map[uint]bool, len(req.Monitors))
+	for _, m := range req.Monitors {
+		if seen[m.TaskID] {
+			return fmt.Errorf("task %d is listed twice", m.TaskID)
+		}
+		seen[m.TaskID] = true
+		taskIDs = append(taskIDs, m.TaskID)
+	}
+	tasks, err := s.taskRepo.FindByIDsAndOrgID(taskIDs, page.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if len(tasks) != len(taskIDs) {
+		return errors.New("one or more tasks not found")
+	}
+
+	monitors := make([]models.StatusPageMonitor, 0, len(req.Monitors))
+	for i, m := range req.Monitors {
+		name := strings.TrimSpace(m.Name)
+		if name == "" && !req.ShowURLs {
+			return fmt.Errorf("task %d needs a name unless showUrls is set", m.TaskID)
+		}
+		monitors = append(monitors, models.StatusPageMonitor{
+			TaskID:    m.TaskID,
+			Name:      name,
+			GroupName: strings.TrimSpace(m.Group),
+			Position:  i,
+		})
+	}
+
+	page.Slug = slug
+	page.Title = title
+	page.Description = strings.TrimSpace(req.Description)
+	page.LogoURL = req.LogoURL
+	page.AccentColor = req.AccentColor
+	page.ShowURLs = req.ShowURLs
+	page.Monitors = monitors
+	return nil
+}
+
+// PublicStatus builds the public view of a page from the tasks' daily
+// rollups and incidents.
+func (s *statusPageService) PublicStatus(slug string) (*PublicStatusPage, error) {
+	page, err := s.repo.FindBySlug(strings.ToLower(slug))
+	if err != nil {
+		return nil, ErrStatusPageNotFound
+	}
+
+	taskIDs := make([]uint, len(page.Monitors))
+	for i, m := range page.Monitors {
+		taskIDs[i] = m.TaskID
+	}
+	// Tasks deleted since the page was saved simply drop off it.
+	tasks, err := s.taskRepo.FindByIDsAndOrgID(taskIDs, page.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	taskByID := make(map[uint]models.Task, len(tasks))
+	for _, t := range tasks {
+		taskByID[t.ID] = t
+	}
+
+	now := time.Now().UTC()
+	since := now.Truncate(24*time.Hour).AddDate(0, 0, -(statsDays - 1))
+	rollups, err := s.statRepo.ListDailyByTaskIDs(taskIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	incidents, err := s.incidentRepo.ListByTaskIDs(taskIDs, since)
+	if err != nil {
+		return nil, err
+	}
+	open, err := s.incidentRepo.OpenTaskIDs(taskIDs)
+	if err != nil {
+		return nil, err
+	}
+	rollupsByTask := make(map[uint][]models.DailyStat)
+	for _, r := range rollups {
+		rollupsByTask[r.TaskID] = append(rollupsByTask[r.TaskID], r)
+	}
+	incidentsByTask := make(map[uint][]models.Incident)
+	for _, inc := range incidents {
+		incidentsByTask[inc.TaskID] = append(incidentsByTask[inc.TaskID], inc)
+	}
+
+	view := &PublicStatusPage{
+		Slug:          page.Slug,
+		Title:         page.Title,
+		Description:   page.Description,
+		LogoURL:       page.LogoURL,
+		AccentColor:   page.AccentColor,
+		Announcements: []PublicAnnouncement{},
+		Groups:        []StatusGroup{},
+		Incidents:     []PublicIncident{},
+		GeneratedAt:   now,
+	}
+	groupIndex := make(map[string]int)
+	names := make(map[uint]string, len(page.Monitors))
+	var active, down int
+	for _, m := range page.Monitors {
+		task, ok := taskByID[m.TaskID]
+		if !ok {
+			continue
+		}
+
+		monitor := PublicMonitor{ID: m.ID, Name: m.Name}
+		if page.ShowURLs {
+			monitor.URL = task.URL
+			if monitor.Name == "" {
+				monitor.Name = task.URL
+			}
+		}
+		switch {
+		case !task.IsActive:
+			monitor.Status = "paused"
+		case open[task.ID]:
+			monitor.Status = "down"
+			active++
+			down++
+		default:
+			monitor.Status = "up"
+			active++
+		}
+		monitor.Days = dailySeries(since, statsDays, rollupsByTask[task.ID], incidentsByTask[task.ID])
+		var checks, failures int64
+		for _, d := range monitor.Days {
+			checks += d.Checks
+			failures += d.Failures
+		}
+		if checks > 0 {
+			monitor.Uptime90d = ratio(checks-failures, checks)
+		}
+		names[task.ID] = monitor.Name
+
+		i, ok := groupIndex[m.GroupName]
+		if !ok {
+			i = len(view.Groups)
+			groupIndex[m.GroupName] = i
+			view.Groups = append(view.Groups, StatusGroup{Name: m.GroupName})
+		}
+		view.Groups[i].Monitors = append(view.Groups[i].Monitors, monitor)
+	}
+
+	announcements, err := s.announcementRepo.ListByPageID(page.ID, now.AddDate(0, 0, -announcementDays), maxAnnouncements)
+	if err != nil {
+		return nil, err
+	}
+	var openIncident, maintenance bool
+	for _, a := range announcements {
+		public := PublicAnnouncement{
+			Kind:           a.Kind,
+			Title:          a.Title,
+			Status:         a.Status,
+			ScheduledStart: a.ScheduledStart,
+			ScheduledEnd:   a.ScheduledEnd,
+			ClosedAt:       a.ClosedAt,
+			Updates:        make([]PublicAnnouncementUpdate, 0, len(a.Updates)),
+		}
+		for _, u := range a.Updates {
+			public.Updates = append(public.Updates, PublicAnnouncementUpdate{Status: u.Status, Message: u.Message, PostedAt: u.CreatedAt})
+		}
+		view.Announcements = append(view.Announcements, public)
+
+		if a.ClosedAt == nil {
+			openIncident = openIncident || a.Kind == models.AnnouncementIncident
+			maintenance = maintenance || (a.Kind == models.AnnouncementMaintenance && a.Status == "in_progress")
+		}
+	}
+
+	// Announced incidents count as an outage even when no probe fails.
+	switch {
+	case down > 0 && down == active:
+		view.Status = StatusMajorOutage
+	case down > 0 || openIncident:
+		view.Status = StatusPartialOutage
+	case maintenance:
+		view.Status = StatusMaintenance
+	default:
+		view.Status = StatusOperational
+	}
+
+	recent := now.AddDate(0, 0, -statusIncidentDays)
+	for _, inc := range incidents {
+		name, ok := names[inc.TaskID]
+		if !ok || inc.StartedAt.Before(recent) {
+			continue
+		}
+		view.Incidents = append(view.Incidents, PublicIncident{
+			Monitor:    name,
+			StartedAt:  inc.StartedAt,
+			ResolvedAt: inc.ResolvedAt,
+			RespCode:   inc.RespCode,
+		})
+		if len(view.Incidents) == maxStatusIncidents {
+			break
+		}
+	}
+	return view, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+	"upbot-server-go/internal/events"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// streamBuffer is how many events a slow client may fall behind before
+// further events to it are dropped.
+const streamBuffer = 64
+
+// StreamService fans live monitor events out to the connected dashboards of
+// this replica. Each replica holds a single Redis subscription however many
+// clients are connected.
+type StreamService interface {
+	// Subscribe returns the events for orgID until ctx is done, when the
+	// channel is closed.
+	Subscribe(ctx context.Context, orgID uint) <-chan events.MonitorEvent
+}
+
+type streamService struct {
+	redisClient *redis.Client
+
+	mu      sync.Mutex
+	clients map[chan events.MonitorEvent]uint
+	started bool
+}
+
+// NewStreamService creates a new instance of StreamService.
+func NewStreamService(redisClient *redis.Client) StreamService {
+	return &streamService{
+		redisClient: redisClient,
+		clients:     make(map[chan events.MonitorEvent]uint),
+	}
+}
+
+func (s *streamService) Subscribe(ctx context.Context, orgID uint) <-chan events.MonitorEvent {
+	ch := make(chan events.MonitorEvent, streamBuffer)
+
+	s.mu.Lock()
+	s.clients[ch] = orgID
+	if !s.started {
+		s.started = true
+		go s.listen()
+	}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+// listen relays the Redis channel for the life of the process, resubscribing
+// after connection errors.
+func (s *streamService) listen() {
+	ctx := context.Background()
+	for {
+		pubsub := s.redisClient.Subscribe(ctx, events.Channel)
+		for msg := range pubsub.Channel() {
+			var event events.MonitorEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				continue
+			}
+			s.broadcast(event)
+		}
+		pubsub.Close()
+		time.Sleep(time.Second)
+	}
+}
+
+func (s *streamService) broadcast(event events.MonitorEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch, orgID := range s.clients {
+		if orgID != event.OrganizationID {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"time"
+	"upbot-server-go/internal/metrics"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// handleAnnouncement sends a status page update to each confirmed
+// subscriber. Subscribers chose to follow the page, so these messages skip
+// the grouping and throttling applied to alerts.
+func (w *NotificationWorker) handleAnnouncement(ctx context.Context, updateID uint) {
+	update, err := w.announcementRepo.WithContext(ctx).FindUpdate(updateID)
+	if err != nil || update.Announcement == nil {
+		slog.ErrorContext(ctx, "Error fetching announcement update", "error", err)
+		return
+	}
+	announcement := update.Announcement
+	page, err := w.statusPageRepo.WithContext(ctx).FindByID(announcement.StatusPageID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching status page", "status_page_id", announcement.StatusPageID, "error", err)
+		return
+	}
+	subscribers, err := w.announcementRepo.WithContext(ctx).ListConfirmedSubscribers(page.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching subscribers", "status_page_id", page.ID, "error", err)
+		return
+	}
+
+	pageURL := w.publicURL + "/status/" + page.Slug
+	for _, subscriber := range subscribers {
+		event := notifier.Event{
+			Type:      notifier.EventAnnouncement,
+			StartedAt: update.CreatedAt,
+			Announcement: &notifier.Announcement{
+				Page:           page.Title,
+				PageURL:        pageURL,
+				Kind:           announcement.Kind,
+				Title:          announcement.Title,
+				Status:         update.Status,
+				Message:        update.Message,
+				ScheduledStart: announcement.ScheduledStart,
+				ScheduledEnd:   announcement.ScheduledEnd,
+				PostedAt:       update.CreatedAt,
+				UnsubscribeURL: pageURL + "/unsubscribe?token=" + url.QueryEscape(subscriber.Token),
+			},
+		}
+		w.notifySubscriber(ctx, subscriber, event)
+	}
+}
+
+func (w *NotificationWorker) notifySubscriber(ctx context.Context, subscriber models.StatusSubscriber, event notifier.Event) {
+	n, kind := notifier.NewPublicWebhookNotifier(subscriber.WebhookURL), "webhook"
+	if subscriber.Email != "" {
+		n, kind = notifier.NewEmailNotifier(w.emailClient, subscriber.Email), "email"
+	}
+
+	ctx, span := tracing.Start(ctx, "notification.deliver", trace.WithAttributes(
+		attribute.String("upbot.channel.type", kind),
+		attribute.Int("upbot.subscriber.id", int(subscriber.ID)),
+	))
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	err := n.Notify(ctx, event)
+	tracing.End(span, err)
+	metrics.NotificationsTotal.WithLabelValues(kind, metrics.Result(err == nil)).Inc()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to deliver announcement", "subscriber_id", subscriber.ID, "channel_type", kind, "error", err)
+		return
+	}
+	slog.DebugContext(ctx, "Delivered announcement", "subscriber_id", subscriber.ID, "channel_type", kind)
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+	"upbot-server-go/internal/logging"
+	"upbot-server-go/internal/repository"
+)
+
+const compactInterval = 5 * time.Minute
+
+// Compactor keeps the hourly and daily rollups up to date and enforces the
+// retention of raw logs and hourly rows. Daily rows are kept indefinitely.
+// Plans with a shorter log retention have their logs pruned earlier.
+type Compactor struct {
+	logRepo         repository.LogRepository
+	statRepo        repository.StatRepository
+	planRepo        repository.PlanRepository
+	plans           PlanLookup
+	logRetention    time.Duration
+	hourlyRetention time.Duration
+}
+
+func NewCompactor(logRepo repository.LogRepository, statRepo repository.StatRepository, planRepo repository.PlanRepository, plans PlanLookup, logRetentionDays, hourlyRetentionDays int) *Compactor {
+	return &Compactor{
+		logRepo:         logRepo,
+		statRepo:        statRepo,
+		planRepo:        planRepo,
+		plans:           plans,
+		logRetention:    time.Duration(logRetentionDays) * 24 * time.Hour,
+		hourlyRetention: time.Duration(hourlyRetentionDays) * 24 * time.Hour,
+	}
+}
+
+func (c *Compactor) Start() {
+	slog.Info("Starting compactor")
+
+	// Backfill every whole day still covered by raw logs, e.g. after
+	// downtime. The oldest day is partially pruned and must not be rewritten.
+	c.compact(time.Now().Add(-c.logRetention).UTC().Truncate(24 * time.Hour).Add(24 * time.Hour))
+	for {
+		time.Sleep(compactInterval)
+		// Re-roll the previous period too so late writes are picked up.
+		c.compact(time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour))
+	}
+}
+
+func (c *Compactor) compact(from time.Time) {
+	ctx := logging.With(context.Background(), "worker", "compactor", "job_id", logging.NewID())
+	now := time.Now()
+
+	if err := c.statRepo.RollupHourly(from, now); err != nil {
+		slog.ErrorContext(ctx, "Error rolling up hourly stats", "from", from, "error", err)
+		return
+	}
+	if err := c.statRepo.RollupDaily(from, now); err != nil {
+		slog.ErrorContext(ctx, "Error rolling up daily stats", "from", from, "error", err)
+		return
+	}
+
+	// Only prune once the rollups covering the data have been written.
+	if n, err := c.logRepo.DeleteBefore(now.Add(-c.logRetention)); err != nil {
+		slog.ErrorContext(ctx, "Error pruning logs", "error", err)
+	} else if n > 0 {
+		slog.InfoContext(ctx, "Pruned logs past retention", "count", n, "retention", c.logRetention.String())
+	}
+	if n, err := c.planRepo.DeleteExpiredLogs(c.plans.DefaultPlanID()); err != nil {
+		slog.ErrorContext(ctx, "Error pruning logs by plan", "error", err)
+	} else if n > 0 {
+		slog.InfoContext(ctx, "Pruned logs past their plan's retention", "count", n)
+	}
+	if _, err := c.statRepo.DeleteHourlyBefore(now.Add(-c.hourlyRetention)); err != nil {
+		slog.ErrorContext(ctx, "Error pruning hourly stats", "error", err)
+	}
+}
@@ -0,0 +1,209 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+	"upbot-server-go/internal/logging"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
+	"upbot-server-go/internal/tracing"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Events for the same destination are buffered in Redis for a short window
+// and delivered as one summary message, so that a shared outage produces a
+// single alert instead of one per task. Each destination is also limited to
+// a number of messages per minute; throttled groups simply stay buffered.
+const (
+	defaultGroupWindow        = 30 * time.Second
+	defaultRateLimitPerMinute = 5
+)
+
+// destination identifies where a notification goes; exactly one field is set.
+type destination struct {
+	ChannelID uint   `json:"channelId,omitempty"`
+	Webhook   string `json:"webhook,omitempty"`
+	Email     string `json:"email,omitempty"`
+}
+
+func (d destination) key() string {
+	switch {
+	case d.ChannelID != 0:
+		return fmt.Sprintf("channel:%d", d.ChannelID)
+	case d.Webhook != "":
+		sum := sha256.Sum256([]byte(d.Webhook))
+		return "discord:" + hex.EncodeToString(sum[:8])
+	default:
+		return "email:" + d.Email
+	}
+}
+
+type pendingNotification struct {
+	Destination  destination       `json:"destination"`
+	Event        notifier.Event    `json:"event"`
+	TraceContext map[string]string `json:"traceContext,omitempty"`
+}
+
+// dispatch delivers incident-tool events right away and buffers everything
+// else for grouping.
+func (w *NotificationWorker) dispatch(ctx context.Context, t target, event notifier.Event) {
+	if t.channel != nil && !notifier.SupportsBatching(t.channel.Type) {
+		w.deliver(ctx, t, event)
+		return
+	}
+
+	key := t.dest.key()
+	payload, err := json.Marshal(pendingNotification{Destination: t.dest, Event: event, TraceContext: tracing.Inject(ctx)})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding notification", "destination", key, "error", err)
+		return
+	}
+
+	if err := w.redisClient.RPush(ctx, "noti_pending:"+key, payload).Err(); err != nil {
+		slog.ErrorContext(ctx, "Error buffering notification, delivering now", "destination", key, "error", err)
+		w.deliver(ctx, t, event)
+		return
+	}
+	// NX keeps the deadline set by the first event of the group.
+	w.redisClient.ZAddNX(ctx, "noti_due", &redis.Z{
+		Score:  float64(flushAt(t.channel, time.Now()).Unix()),
+		Member: key,
+	})
+}
+
+func flushAt(channel *models.Channel, now time.Time) time.Time {
+	if channel != nil && channel.DigestMode == models.DigestHourly {
+		return now.Truncate(time.Hour).Add(time.Hour)
+	}
+	window := defaultGroupWindow
+	if channel != nil && channel.GroupWindowSeconds > 0 {
+		window = time.Duration(channel.GroupWindowSeconds) * time.Second
+	}
+	return now.Add(window)
+}
+
+func (w *NotificationWorker) flushLoop(ctx context.Context) {
+	for {
+		w.flushDue(ctx)
+		time.Sleep(1 * time.Second)
+	}
+}
+
+func (w *NotificationWorker) flushDue(ctx context.Context) {
+	keys, err := w.redisClient.ZRangeByScore(ctx, "noti_due", &redis.ZRangeBy{
+		Min: "-inf",
+		Max: fmt.Sprintf("%d", time.Now().Unix()),
+	}).Result()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error fetching due notifications", "error", err)
+		return
+	}
+
+	for _, key := range keys {
+		w.flush(ctx, key)
+	}
+}
+
+func (w *NotificationWorker) flush(ctx context.Context, key string) {
+	listKey := "noti_pending:" + key
+	ctx = logging.With(ctx, "destination", key)
+
+	first, err := w.redisClient.LIndex(ctx, listKey, 0).Result()
+	if err == redis.Nil {
+		w.redisClient.ZRem(ctx, "noti_due", key)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error reading pending notifications", "error", err)
+		return
+	}
+
+	var head pendingNotification
+	if err := json.Unmarshal([]byte(first), &head); err != nil {
+		slog.WarnContext(ctx, "Dropping malformed notifications", "error", err)
+		w.redisClient.Del(ctx, listKey)
+		w.redisClient.ZRem(ctx, "noti_due", key)
+		return
+	}
+
+	t := target{dest: head.Destination}
+	if head.Destination.ChannelID != 0 {
+		channel, err := w.channelRepo.WithContext(ctx).FindByID(head.Destination.ChannelID)
+		if err != nil {
+			slog.WarnContext(ctx, "Dropping notifications for deleted channel", "channel_id", head.Destination.ChannelID)
+			w.redisClient.Del(ctx, listKey)
+			w.redisClient.ZRem(ctx, "noti_due", key)
+			return
+		}
+		t.channel = channel
+	}
+
+	if !w.allow(ctx, key, rateLimit(t.channel)) {
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+		w.redisClient.ZAdd(ctx, "noti_due", &redis.Z{Score: float64(next.Unix()), Member: key})
+		return
+	}
+
+	// Take the whole group atomically so events pushed meanwhile start a new one.
+	pipe := w.redisClient.TxPipeline()
+	entries := pipe.LRange(ctx, listKey, 0, -1)
+	pipe.Del(ctx, listKey)
+	pipe.ZRem(ctx, "noti_due", key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.ErrorContext(ctx, "Error taking pending notifications", "error", err)
+		return
+	}
+
+	var events []notifier.Event
+	var links []trace.Link
+	for _, raw := range entries.Val() {
+		var p pendingNotification
+		if err := json.Unmarshal([]byte(raw), &p); err != nil {
+			slog.WarnContext(ctx, "Skipping malformed notification", "error", err)
+			continue
+		}
+		events = append(events, p.Event)
+		if link, ok := tracing.Link(p.TraceContext); ok {
+			links = append(links, link)
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	// A summary answers several checks, so its trace links to each of them
+	// rather than continuing one.
+	ctx, span := tracing.Start(ctx, "notification.flush", trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("upbot.notification.events", len(events))))
+	defer span.End()
+	ctx = logging.With(ctx, "events", len(events))
+	w.deliver(ctx, t, notifier.Summarize(events))
+}
+
+func rateLimit(channel *models.Channel) int {
+	if channel != nil && channel.RateLimitPerMinute > 0 {
+		return channel.RateLimitPerMinute
+	}
+	return defaultRateLimitPerMinute
+}
+
+// allow counts a message against the destination's per-minute budget.
+func (w *NotificationWorker) allow(ctx context.Context, key string, limit int) bool {
+	bucket := fmt.Sprintf("noti_rate:%s:%d", key, time.Now().Unix()/60)
+	count, err := w.redisClient.Incr(ctx, bucket).Result()
+	if err != nil {
+		return true
+	}
+	if count == 1 {
+		w.redisClient.Expire(ctx, bucket, 2*time.Minute)
+	}
+	return count <= int64(limit)
+}
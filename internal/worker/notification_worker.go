@@ -1,155 +1,245 @@
 package worker
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
 	"strconv"
+	"time"
 	"upbot-server-go/internal/infrastructure"
+	"upbot-server-go/internal/logging"
+	"upbot-server-go/internal/metrics"
+	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
 	"upbot-server-go/internal/repository"
+	"upbot-server-go/internal/tracing"
 
 	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// NotificationJob is the payload pushed onto noti_queue: a task incident
+// event from the ping worker, or a status page announcement update.
+type NotificationJob struct {
+	// ID correlates the log lines of the producer and the worker; jobs
+	// from older producers are given one on arrival.
+	ID string `json:"id,omitempty"`
+
+	TaskID     uint               `json:"taskId"`
+	IncidentID uint               `json:"incidentId"`
+	Type       notifier.EventType `json:"type"`
+	// Duration is how long the check that raised the event took, in
+	// milliseconds.
+	Duration int64 `json:"duration,omitempty"`
+
+	AnnouncementUpdateID uint `json:"announcementUpdateId,omitempty"`
+
+	// TraceContext carries the producer's span so that delivery continues
+	// the trace of the check that raised the alert.
+	TraceContext map[string]string `json:"traceContext,omitempty"`
+}
+
 type NotificationWorker struct {
-	redisClient *redis.Client
-	taskRepo    repository.TaskRepository
-	emailClient infrastructure.EmailClient
+	redisClient      *redis.Client
+	taskRepo         repository.TaskRepository
+	channelRepo      repository.ChannelRepository
+	incidentRepo     repository.IncidentRepository
+	statusPageRepo   repository.StatusPageRepository
+	announcementRepo repository.AnnouncementRepository
+	emailClient      infrastructure.EmailClient
+	dashboardURL     string
+	publicURL        string
 }
 
-func NewNotificationWorker(redisClient *redis.Client, taskRepo repository.TaskRepository, emailClient infrastructure.EmailClient) *NotificationWorker {
+func NewNotificationWorker(redisClient *redis.Client, taskRepo repository.TaskRepository, channelRepo repository.ChannelRepository, incidentRepo repository.IncidentRepository, statusPageRepo repository.StatusPageRepository, announcementRepo repository.AnnouncementRepository, emailClient infrastructure.EmailClient, dashboardURL, publicURL string) *NotificationWorker {
 	return &NotificationWorker{
-		redisClient: redisClient,
-		taskRepo:    taskRepo,
-		emailClient: emailClient,
+		redisClient:      redisClient,
+		taskRepo:         taskRepo,
+		channelRepo:      channelRepo,
+		incidentRepo:     incidentRepo,
+		statusPageRepo:   statusPageRepo,
+		announcementRepo: announcementRepo,
+		emailClient:      emailClient,
+		dashboardURL:     dashboardURL,
+		publicURL:        publicURL,
 	}
 }
 
 func (w *NotificationWorker) Start() {
-	log.Println("Starting Notification Worker...")
+	slog.Info("Starting notification worker")
+	ctx := logging.With(context.Background(), "worker", "notification")
+	go w.flushLoop(ctx)
 	for {
 		// Blocking pop
-		result, err := w.redisClient.BRPop(context.Background(), 0, "noti_queue").Result()
+		result, err := w.redisClient.BRPop(ctx, 0, "noti_queue").Result()
 		if err != nil {
-			log.Printf("Error fetching from notification queue: %v", err)
+			slog.ErrorContext(ctx, "Error fetching from notification queue", "error", err)
 			continue
 		}
 
 		if len(result) == 2 {
-			taskIDStr := result[1]
-			w.handleTask(taskIDStr)
+			w.handleJob(ctx, result[1])
 		}
 	}
 }
 
-func (w *NotificationWorker) handleTask(taskIDStr string) {
-	taskID, err := strconv.Atoi(taskIDStr)
+func parseNotificationJob(raw string) (NotificationJob, bool) {
+	var job NotificationJob
+	if err := json.Unmarshal([]byte(raw), &job); err == nil && (job.TaskID != 0 || job.AnnouncementUpdateID != 0) {
+		if job.AnnouncementUpdateID != 0 {
+			job.Type = notifier.EventAnnouncement
+		}
+		return job, true
+	}
+	// Older producers push the bare task ID for a failure.
+	taskID, err := strconv.Atoi(raw)
 	if err != nil {
-		log.Printf("Invalid task ID: %s", taskIDStr)
+		return job, false
+	}
+	return NotificationJob{TaskID: uint(taskID), Type: notifier.EventDown}, true
+}
+
+func (w *NotificationWorker) handleJob(ctx context.Context, raw string) {
+	job, ok := parseNotificationJob(raw)
+	if !ok {
+		slog.WarnContext(ctx, "Dropping invalid notification job", "payload", raw)
 		return
 	}
+	if job.ID == "" {
+		job.ID = logging.NewID()
+	}
 
-	task, err := w.taskRepo.FindByID(uint(taskID))
+	ctx, span := tracing.Start(tracing.Extract(ctx, job.TraceContext), "notification.job",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("upbot.event.type", string(job.Type)),
+			attribute.Int("upbot.task.id", int(job.TaskID)),
+			attribute.Int("upbot.incident.id", int(job.IncidentID)),
+		))
+	defer span.End()
+	ctx = logging.With(ctx, "job_id", job.ID, "event", job.Type)
+
+	if job.Type == notifier.EventAnnouncement {
+		ctx = logging.With(ctx, "announcement_update_id", job.AnnouncementUpdateID)
+		w.handleAnnouncement(ctx, job.AnnouncementUpdateID)
+		return
+	}
+
+	ctx = logging.With(ctx, "task_id", job.TaskID, "incident_id", job.IncidentID)
+	task, err := w.taskRepo.WithContext(ctx).FindByID(job.TaskID)
 	if err != nil {
-		log.Printf("Error fetching task %d: %v", taskID, err)
+		slog.ErrorContext(ctx, "Error fetching task", "error", err)
 		return
 	}
 
-	// We need the user email to send notification
-	// The current repo doesn't have a way to get user by ID easily unless we add it or preload it.
-	// Let's assume we can get the user via the task's UserID.
-	// I'll add GetUserByID to repo or just use a direct DB call if I had it, but I don't have DB here.
-	// I'll assume the taskRepo can fetch the user or I'll skip email for now if I can't get it easily.
-	// Actually, I can just add GetUserByID to the repo interface.
-	// But for now, let's implement the Discord part which is easier.
+	event := notifier.Event{
+		Type:       job.Type,
+		TaskID:     task.ID,
+		IncidentID: job.IncidentID,
+		URL:        task.URL,
+		StartedAt:  time.Now(),
+		Duration:   job.Duration,
 
-	if task.NotifyDiscord && task.WebHook != nil {
-		if err := w.sendToDiscordWebhook(task.WebHook, task.URL); err != nil {
-			log.Printf("Failed to send Discord notification: %v", err)
+		DashboardURL: w.dashboardURL,
+	}
+	if job.IncidentID != 0 {
+		if incident, err := w.incidentRepo.WithContext(ctx).FindByID(job.IncidentID); err == nil {
+			event.StartedAt = incident.StartedAt
+			event.ResolvedAt = incident.ResolvedAt
+			event.StatusCode = incident.RespCode
+			event.Error = incident.Cause
 		}
-	} else {
-		// Email logic requires user email.
-		// TODO: Fetch user email and send email.
-		log.Printf("Email notification skipped (User fetch not implemented yet) for task %d", taskID)
 	}
-}
 
-type DiscordEmbed struct {
-	Title       string       `json:"title"`
-	Description string       `json:"description"`
-	Color       int          `json:"color"`
-	Fields      []EmbedField `json:"fields,omitempty"`
-	Footer      EmbedFooter  `json:"footer,omitempty"`
+	for _, t := range w.targetsFor(ctx, task) {
+		w.dispatch(ctx, t, event)
+	}
 }
 
-type EmbedField struct {
-	Name   string `json:"name"`
-	Value  string `json:"value"`
-	Inline bool   `json:"inline"`
+// target is one destination of a task's notifications. channel is nil for
+// the legacy per-task webhook and the email fallback.
+type target struct {
+	dest    destination
+	channel *models.Channel
 }
 
-type EmbedFooter struct {
-	Text string `json:"text"`
-	Icon string `json:"icon_url"`
+// kind is the channel type used to label delivery metrics.
+func (t target) kind() string {
+	switch {
+	case t.channel != nil:
+		return t.channel.Type
+	case t.dest.Webhook != "":
+		return "discord"
+	default:
+		return "email"
+	}
 }
 
-type DiscordWebhookPayload struct {
-	Embeds []DiscordEmbed `json:"embeds"`
-}
+// targetsFor resolves the destinations of a task: its attached channels,
+// the legacy per-task Discord webhook, and the owner's email as a fallback
+// when nothing else is configured.
+func (w *NotificationWorker) targetsFor(ctx context.Context, task *models.Task) []target {
+	var targets []target
 
-func (w *NotificationWorker) sendToDiscordWebhook(webhook *string, url string) error {
-	if webhook == nil || *webhook == "" {
-		return fmt.Errorf("no Discord webhook URL provided")
-	}
-
-	embed := DiscordEmbed{
-		Title:       "🚨 Server Ping Failure Alert 🚨",
-		Description: fmt.Sprintf("We have detected multiple ping failures for the server at %s.", url),
-		Color:       16711680,
-		Fields: []EmbedField{
-			{
-				Name:   "Server URL",
-				Value:  fmt.Sprintf("[Visit Server](%s)", url),
-				Inline: false,
-			},
-			{
-				Name:   "Status",
-				Value:  "❌ Failed to respond",
-				Inline: true,
-			},
-		},
-		Footer: EmbedFooter{
-			Text: "Please take immediate action.",
-		},
-	}
-
-	payload := DiscordWebhookPayload{
-		Embeds: []DiscordEmbed{embed},
-	}
-
-	payloadBytes, err := json.Marshal(payload)
+	channels, err := w.channelRepo.WithContext(ctx).ListByTaskID(task.ID)
 	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %v", err)
+		slog.ErrorContext(ctx, "Error fetching channels", "error", err)
+	}
+	for i := range channels {
+		targets = append(targets, target{
+			dest:    destination{ChannelID: channels[i].ID},
+			channel: &channels[i],
+		})
 	}
 
-	req, err := http.NewRequest("POST", *webhook, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %v", err)
+	if task.NotifyDiscord && task.WebHook != nil && *task.WebHook != "" {
+		targets = append(targets, target{dest: destination{Webhook: *task.WebHook}})
+	}
+
+	if len(targets) == 0 {
+		user, err := w.taskRepo.WithContext(ctx).GetUserByID(task.UserID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error fetching task owner", "user_id", task.UserID, "error", err)
+			return nil
+		}
+		targets = append(targets, target{dest: destination{Email: user.Email}})
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	return targets
+}
+
+func (w *NotificationWorker) notifierFor(t target) (notifier.Notifier, error) {
+	switch {
+	case t.channel != nil:
+		return notifier.New(t.channel, w.emailClient)
+	case t.dest.Webhook != "":
+		return notifier.NewDiscordNotifier(t.dest.Webhook), nil
+	default:
+		return notifier.NewEmailNotifier(w.emailClient, t.dest.Email), nil
+	}
+}
+
+func (w *NotificationWorker) deliver(ctx context.Context, t target, event notifier.Event) {
+	n, err := w.notifierFor(t)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %v", err)
+		slog.WarnContext(ctx, "Skipping notification", "destination", t.dest.key(), "error", err)
+		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("discord webhook returned non-OK status: %s", resp.Status)
+	ctx, span := tracing.Start(ctx, "notification.deliver", trace.WithAttributes(
+		attribute.String("upbot.channel.type", t.kind()),
+		attribute.Int("upbot.channel.id", int(t.dest.ChannelID)),
+		attribute.String("upbot.event.type", string(event.Type)),
+	))
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	err = n.Notify(ctx, event)
+	tracing.End(span, err)
+	metrics.NotificationsTotal.WithLabelValues(t.kind(), metrics.Result(err == nil)).Inc()
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to deliver notification", "destination", t.dest.key(), "channel_type", t.kind(), "error", err)
+		return
 	}
-	return nil
+	slog.InfoContext(ctx, "Delivered notification", "destination", t.dest.key(), "channel_type", t.kind())
 }
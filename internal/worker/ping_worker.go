@@ -3,37 +3,68 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
+	"upbot-server-go/internal/checker"
+	"upbot-server-go/internal/events"
+	"upbot-server-go/internal/logging"
+	"upbot-server-go/internal/metrics"
 	"upbot-server-go/internal/models"
+	"upbot-server-go/internal/notifier"
 	"upbot-server-go/internal/repository"
+	"upbot-server-go/internal/tracing"
 
 	"github.com/go-redis/redis/v8"
-	"gorm.io/gorm"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const (
+	// minPingInterval is the shortest interval the worker will schedule,
+	// whatever the task and plan say.
+	minPingInterval = 30 * time.Second
+	// failureThreshold is the number of consecutive failures that opens an incident.
+	failureThreshold = 2
+	// checkTimeout bounds a single check, so that a target that accepts the
+	// connection but never answers cannot hold up the queue.
+	checkTimeout = 30 * time.Second
+)
+
+// PlanLookup resolves the plan that applies to an organization.
+// service.PlanService implements it.
+type PlanLookup interface {
+	PlanFor(orgID uint) (*models.Plan, error)
+	DefaultPlanID() uint
+}
+
 type PingWorker struct {
-	redisClient *redis.Client
-	taskRepo    repository.TaskRepository
-	logRepo     repository.LogRepository
-	db          *gorm.DB
+	redisClient  *redis.Client
+	taskRepo     repository.TaskRepository
+	logRepo      repository.LogRepository
+	incidentRepo repository.IncidentRepository
+	plans        PlanLookup
+	client       *http.Client
 }
 
-func NewPingWorker(redisClient *redis.Client, taskRepo repository.TaskRepository, logRepo repository.LogRepository, db *gorm.DB) *PingWorker {
+func NewPingWorker(redisClient *redis.Client, taskRepo repository.TaskRepository, logRepo repository.LogRepository, incidentRepo repository.IncidentRepository, plans PlanLookup) *PingWorker {
 	return &PingWorker{
-		redisClient: redisClient,
-		taskRepo:    taskRepo,
-		logRepo:     logRepo,
-		db:          db,
+		redisClient:  redisClient,
+		taskRepo:     taskRepo,
+		logRepo:      logRepo,
+		incidentRepo: incidentRepo,
+		plans:        plans,
+		client:       &http.Client{Timeout: checkTimeout},
 	}
 }
 
 func (w *PingWorker) Start() {
-	log.Println("Starting Ping Worker...")
+	slog.Info("Starting ping worker")
 	for {
 		w.processBatch()
 		time.Sleep(1 * time.Second)
@@ -41,16 +72,16 @@ func (w *PingWorker) Start() {
 }
 
 func (w *PingWorker) processBatch() {
-	ctx := context.Background()
+	ctx := logging.With(context.Background(), "worker", "ping")
 	now := time.Now().Unix()
 
-	tasks, err := w.redisClient.ZRangeByScore(ctx, "ping_queue", &redis.ZRangeBy{
+	tasks, err := w.redisClient.ZRangeByScoreWithScores(ctx, "ping_queue", &redis.ZRangeBy{
 		Min: "-inf",
 		Max: fmt.Sprintf("%d", now),
 	}).Result()
 
 	if err != nil {
-		log.Printf("Error fetching from queue: %v", err)
+		slog.ErrorContext(ctx, "Error fetching from ping queue", "error", err)
 		return
 	}
 
@@ -58,15 +89,24 @@ func (w *PingWorker) processBatch() {
 		return
 	}
 
-	for _, taskStr := range tasks {
-		w.processTask(ctx, taskStr)
+	for _, z := range tasks {
+		taskStr, _ := z.Member.(string)
+		w.processTask(ctx, taskStr, time.Unix(int64(z.Score), 0))
 	}
 }
 
-func (w *PingWorker) processTask(ctx context.Context, taskStr string) {
+// processTask runs one check. Each check is the root of a trace that
+// continues into the notification worker when it raises an alert.
+func (w *PingWorker) processTask(ctx context.Context, taskStr string, dueAt time.Time) {
+	ctx, span := tracing.Start(ctx, "ping.check", trace.WithAttributes(
+		attribute.String("upbot.queue.member", taskStr),
+		attribute.Int64("upbot.queue.lag_ms", time.Since(dueAt).Milliseconds()),
+	))
+	defer span.End()
+
 	parts := strings.SplitN(taskStr, "|", 2)
 	if len(parts) != 2 {
-		log.Printf("Invalid task format: %s", taskStr)
+		slog.WarnContext(ctx, "Dropping invalid ping queue member", "member", taskStr)
 		w.redisClient.ZRem(ctx, "ping_queue", taskStr)
 		return
 	}
@@ -74,90 +114,228 @@ func (w *PingWorker) processTask(ctx context.Context, taskStr string) {
 	taskIDStr, url := parts[0], parts[1]
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		log.Printf("Invalid task ID: %s", taskIDStr)
+		slog.WarnContext(ctx, "Dropping ping queue member with invalid task ID", "member", taskStr)
 		w.redisClient.ZRem(ctx, "ping_queue", taskStr)
 		return
 	}
+	ctx = logging.With(ctx, "task_id", taskID)
 
-	start := time.Now()
-	resp, err := http.Get(url)
-	duration := time.Since(start).Milliseconds()
+	task, err := w.taskRepo.WithContext(ctx).FindByID(uint(taskID))
+	if err != nil {
+		slog.WarnContext(ctx, "Dropping check of missing task", "error", err)
+		w.redisClient.ZRem(ctx, "ping_queue", taskStr)
+		return
+	}
+	span.SetAttributes(
+		attribute.Int("upbot.task.id", int(task.ID)),
+		attribute.Int("upbot.organization.id", int(task.OrganizationID)),
+		attribute.String("upbot.task.type", task.Type),
+	)
+	ctx = logging.With(ctx, "organization_id", task.OrganizationID)
+	interval, reason := w.interval(ctx, task)
+	if reason != "" {
+		w.pause(ctx, task, taskStr, reason)
+		return
+	}
+
+	result := w.probe(ctx, url)
+	metrics.ProbeDuration.WithLabelValues(task.Type, metrics.Result(result.Success)).Observe(result.Duration.Seconds())
+	w.recordCertExpiry(ctx, task, result)
+	slog.DebugContext(ctx, "Check completed", "success", result.Success, "status_code", result.StatusCode, "duration_ms", result.Duration.Milliseconds())
+
+	if result.Success {
+		w.handleSuccess(ctx, task, url, interval, result)
+	} else {
+		w.handleFailure(ctx, task, url, interval, result)
+	}
+}
 
-	if err := w.logRepo.TrimLogs(uint(taskID), 10); err != nil {
-		log.Printf("Error trimming logs: %v", err)
+func (w *PingWorker) probe(ctx context.Context, url string) checker.Result {
+	ctx, span := tracing.Start(ctx, "probe.http", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", url)))
+	result := checker.HTTP(ctx, w.client, url)
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", result.StatusCode),
+		attribute.Bool("upbot.check.success", result.Success),
+	)
+	if !result.Success {
+		span.SetStatus(codes.Error, result.Message)
 	}
+	span.End()
+	return result
+}
 
-	if err != nil || resp.StatusCode != http.StatusOK {
-		w.handleFailure(ctx, uint(taskID), url, duration, err, resp)
+// interval returns how long to wait before checking the task again, or why
+// its organization's plan no longer allows the check at all. After a
+// downgrade, the oldest tasks up to the plan's monitor limit keep running.
+func (w *PingWorker) interval(ctx context.Context, task *models.Task) (time.Duration, string) {
+	interval := time.Duration(task.IntervalSeconds) * time.Second
+	plan, err := w.plans.PlanFor(task.OrganizationID)
+	if err != nil {
+		// Keep checking on the task's own schedule until the plan loads.
+		slog.ErrorContext(ctx, "Error loading plan", "error", err)
 	} else {
-		w.handleSuccess(ctx, uint(taskID), url, duration, resp.StatusCode)
-		resp.Body.Close()
+		if !plan.AllowsType(task.Type) {
+			return 0, "its type is not in its plan"
+		}
+		// Tasks not yet moved into an organization share ID 0 across
+		// users, so only their creation is limited.
+		if plan.MaxMonitors > 0 && task.OrganizationID != 0 {
+			before, err := w.taskRepo.WithContext(ctx).CountActiveTasksBefore(task.OrganizationID, task.ID)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error counting active tasks", "error", err)
+			} else if before >= int64(plan.MaxMonitors) {
+				return 0, "its plan's monitor limit is reached"
+			}
+		}
+		if min := time.Duration(plan.MinIntervalSeconds) * time.Second; interval < min {
+			interval = min
+		}
+	}
+	if interval < minPingInterval {
+		interval = minPingInterval
+	}
+	return interval, ""
+}
+
+// recordCertExpiry stores the expiry of the server certificate when it
+// changes, typically after a renewal.
+func (w *PingWorker) recordCertExpiry(ctx context.Context, task *models.Task, result checker.Result) {
+	expiresAt := result.CertExpiresAt
+	if expiresAt == nil || (task.CertExpiresAt != nil && task.CertExpiresAt.Equal(*expiresAt)) {
+		return
+	}
+	if err := w.taskRepo.WithContext(ctx).UpdateCertExpiry(task.ID, *expiresAt); err != nil {
+		slog.ErrorContext(ctx, "Error saving certificate expiry", "error", err)
+		return
 	}
+	task.CertExpiresAt = expiresAt
 }
 
-func (w *PingWorker) handleSuccess(ctx context.Context, taskID uint, url string, duration int64, statusCode int) {
+// pause stops checking a task that its plan no longer allows.
+func (w *PingWorker) pause(ctx context.Context, task *models.Task, taskStr, reason string) {
+	slog.InfoContext(ctx, "Pausing task: "+reason, "type", task.Type)
+	task.IsActive = false
+	if err := w.taskRepo.WithContext(ctx).Update(task); err != nil {
+		slog.ErrorContext(ctx, "Error pausing task", "error", err)
+	}
+	w.redisClient.ZRem(ctx, "ping_queue", taskStr)
+	w.publish(ctx, events.MonitorEvent{Type: events.TypeState, TaskID: task.ID, OrganizationID: task.OrganizationID, Status: "paused"})
+}
+
+func (w *PingWorker) handleSuccess(ctx context.Context, task *models.Task, url string, interval time.Duration, result checker.Result) {
+	taskID := task.ID
 	newLog := &models.Log{
 		TaskID:      taskID,
 		Time:        time.Now(),
-		TimeTake:    duration,
-		LogResponse: "Successfully pinged",
+		TimeTake:    result.Duration.Milliseconds(),
+		LogResponse: result.Message,
 		IsSuccess:   true,
-		RespCode:    statusCode,
+		RespCode:    result.StatusCode,
 	}
-	w.logRepo.Create(newLog)
-
-	nextPing := time.Now().Add(10 * time.Minute).Unix()
-	taskMember := fmt.Sprintf("%d|%s", taskID, url)
-	w.redisClient.ZAdd(ctx, "ping_queue", &redis.Z{
-		Score:  float64(nextPing),
-		Member: taskMember,
-	})
-}
+	w.logRepo.WithContext(ctx).Create(newLog)
+	w.publishCheck(ctx, task, newLog)
 
-func (w *PingWorker) handleFailure(ctx context.Context, taskID uint, url string, duration int64, reqErr error, resp *http.Response) {
-	statusCode := 0
-	logMsg := "Failed to ping URL"
-	if resp != nil {
-		statusCode = resp.StatusCode
-		resp.Body.Close()
+	if task.FailCount > 0 {
+		w.taskRepo.WithContext(ctx).UpdateFailCount(taskID, 0)
 	}
-	if reqErr != nil {
-		logMsg = reqErr.Error()
+
+	if incident, err := w.incidentRepo.WithContext(ctx).FindOpenByTaskID(taskID); err == nil {
+		if err := w.incidentRepo.WithContext(ctx).Resolve(incident, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "Error resolving incident", "incident_id", incident.ID, "error", err)
+		} else {
+			w.enqueueNotification(ctx, NotificationJob{TaskID: taskID, IncidentID: incident.ID, Type: notifier.EventUp, Duration: newLog.TimeTake})
+			w.publish(ctx, events.MonitorEvent{Type: events.TypeState, TaskID: taskID, OrganizationID: task.OrganizationID, Status: "up", IncidentID: incident.ID})
+		}
 	}
 
+	w.schedule(ctx, taskID, url, interval)
+}
+
+func (w *PingWorker) handleFailure(ctx context.Context, task *models.Task, url string, interval time.Duration, result checker.Result) {
+	taskID := task.ID
 	newLog := &models.Log{
 		TaskID:      taskID,
 		Time:        time.Now(),
-		TimeTake:    duration,
-		LogResponse: logMsg,
+		TimeTake:    result.Duration.Milliseconds(),
+		LogResponse: result.Message,
 		IsSuccess:   false,
-		RespCode:    statusCode,
+		RespCode:    result.StatusCode,
 	}
-	w.logRepo.Create(newLog)
+	w.logRepo.WithContext(ctx).Create(newLog)
+	w.publishCheck(ctx, task, newLog)
 
-	var task models.Task
-	if err := w.db.First(&task, taskID).Error; err != nil {
-		log.Printf("Task not found %d: %v", taskID, err)
+	task.FailCount++
+	w.taskRepo.WithContext(ctx).UpdateFailCount(taskID, task.FailCount)
+
+	// Keep probing a failing task so that recovery can resolve the incident.
+	w.schedule(ctx, taskID, url, interval)
+
+	if task.FailCount < failureThreshold {
+		return
+	}
+	if _, err := w.incidentRepo.WithContext(ctx).FindOpenByTaskID(taskID); err == nil {
 		return
 	}
 
-	task.FailCount++
-	if task.FailCount >= 2 {
-		task.IsActive = false
-		w.db.Save(&task)
-		
-		taskMember := fmt.Sprintf("%d|%s", taskID, url)
-		w.redisClient.ZRem(ctx, "ping_queue", taskMember)
-
-		w.redisClient.LPush(ctx, "noti_queue", taskID)
-	} else {
-		w.db.Model(&task).Update("fail_count", task.FailCount)
-		
-		nextPing := time.Now().Add(10 * time.Minute).Unix()
-		taskMember := fmt.Sprintf("%d|%s", taskID, url)
-		w.redisClient.ZAdd(ctx, "ping_queue", &redis.Z{
-			Score:  float64(nextPing),
-			Member: taskMember,
-		})
+	incident := &models.Incident{
+		TaskID:    taskID,
+		StartedAt: time.Now(),
+		Cause:     result.Message,
+		RespCode:  result.StatusCode,
+	}
+	if err := w.incidentRepo.WithContext(ctx).Create(incident); err != nil {
+		slog.ErrorContext(ctx, "Error opening incident", "error", err)
+		return
+	}
+	w.enqueueNotification(ctx, NotificationJob{TaskID: taskID, IncidentID: incident.ID, Type: notifier.EventDown, Duration: newLog.TimeTake})
+	w.publish(ctx, events.MonitorEvent{Type: events.TypeState, TaskID: taskID, OrganizationID: task.OrganizationID, Status: "down", IncidentID: incident.ID})
+}
+
+func (w *PingWorker) schedule(ctx context.Context, taskID uint, url string, after time.Duration) {
+	taskMember := fmt.Sprintf("%d|%s", taskID, url)
+	w.redisClient.ZAdd(ctx, "ping_queue", &redis.Z{
+		Score:  float64(time.Now().Add(after).Unix()),
+		Member: taskMember,
+	})
+}
+
+func (w *PingWorker) enqueueNotification(ctx context.Context, job NotificationJob) {
+	job.ID = logging.NewID()
+	job.TraceContext = tracing.Inject(ctx)
+	ctx = logging.With(ctx, "job_id", job.ID, "incident_id", job.IncidentID, "event", job.Type)
+	payload, err := json.Marshal(job)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error encoding notification job", "error", err)
+		return
+	}
+	if err := w.redisClient.LPush(ctx, "noti_queue", payload).Err(); err != nil {
+		slog.ErrorContext(ctx, "Error queueing notification", "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "Queued notification")
+}
+
+func (w *PingWorker) publishCheck(ctx context.Context, task *models.Task, l *models.Log) {
+	success, latency := l.IsSuccess, l.TimeTake
+	w.publish(ctx, events.MonitorEvent{
+		Type:           events.TypeCheck,
+		TaskID:         task.ID,
+		OrganizationID: task.OrganizationID,
+		Time:           l.Time,
+		Success:        &success,
+		RespCode:       l.RespCode,
+		LatencyMs:      &latency,
+		Message:        l.LogResponse,
+	})
+}
+
+// publish feeds the live stream; a failure only costs dashboards an update.
+func (w *PingWorker) publish(ctx context.Context, event events.MonitorEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if err := events.Publish(ctx, w.redisClient, event); err != nil {
+		slog.ErrorContext(ctx, "Error publishing monitor event", "event", event.Type, "error", err)
 	}
 }